@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/backtest"
+	"VOID/internal/db"
+	"VOID/internal/services"
+)
+
+// runBacktest implements `VOID backtest`: replay PricingHistory under the current pricing config
+// and each candidate file given as an argument.
+//
+//	VOID backtest -from 2025-10-01 -to 2025-11-01 -format csv -out rows.csv -summary summary.csv candidate.yaml
+func runBacktest(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	fromStr := fs.String("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"), "start date (inclusive), YYYY-MM-DD")
+	toStr := fs.String("to", time.Now().AddDate(0, 0, 1).Format("2006-01-02"), "end date (exclusive), YYYY-MM-DD")
+	format := fs.String("format", "csv", "output format: csv or json")
+	out := fs.String("out", "", "per-row output file (csv) or full report (json); default stdout")
+	summary := fs.String("summary", "", "summary output file for csv; default stderr")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: VOID backtest [flags] [candidate.yaml ...]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	from, err := time.ParseInLocation("2006-01-02", *fromStr, time.Local)
+	if err != nil {
+		log.Fatalf("invalid -from: %v", err)
+	}
+	to, err := time.ParseInLocation("2006-01-02", *toStr, time.Local)
+	if err != nil {
+		log.Fatalf("invalid -to: %v", err)
+	}
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("invalid -format %q", *format)
+	}
+
+	gormDB, err := db.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("db init failed: %v", err)
+	}
+	migrate(gormDB, cfg)
+	current := cfg.Pricing
+	if v, err := services.LatestPricingVersion(gormDB); err != nil {
+		log.Printf("no stored pricing config, using %s: %v", config.Path(), err)
+	} else if v != nil {
+		current = v.Pricing
+	}
+
+	candidates := []backtest.Candidate{{Name: "current", Pricing: current}}
+	for _, path := range fs.Args() {
+		pcfg, err := config.LoadPricingFile(path, current)
+		if err != nil {
+			log.Fatalf("candidate %s: %v", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		candidates = append(candidates, backtest.Candidate{Name: name, Pricing: pcfg})
+	}
+
+	history, err := backtest.LoadHistory(gormDB, from, to)
+	if err != nil {
+		log.Fatalf("load history: %v", err)
+	}
+	res, err := backtest.Run(history, candidates)
+	if err != nil {
+		log.Fatalf("backtest: %v", err)
+	}
+	res.From, res.To = from, to
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		if err := backtest.WriteJSON(w, res); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := backtest.WriteRowsCSV(w, res.Rows); err != nil {
+		log.Fatal(err)
+	}
+	var sw io.Writer = os.Stderr
+	if *summary != "" {
+		f, err := os.Create(*summary)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		sw = f
+	}
+	if err := backtest.WriteSummaryCSV(sw, res); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,22 +23,237 @@ type JWTCfg struct {
 	Secret string `yaml:"secret"`
 }
 type PricingCfg struct {
+	Currency       string  `yaml:"currency"` // ISO 4217; all amounts below are in its major unit
+	Rounding       string  `yaml:"rounding"` // half_up (default), half_even, down, up
 	BasePrice      float64 `yaml:"base_price"`
 	DistanceWeight float64 `yaml:"distance_weight"`
 	TrafficWeight  float64 `yaml:"traffic_weight"`
-	DemandWeight   float64 `yaml:"demand_weight"`
+
+	// surge: base * (1 + (max_surge_multiplier-1) * min(demand/peak_demand, 1)) - base
+	MaxSurgeMultiplier float64 `yaml:"max_surge_multiplier"`
+	PeakDemand         float64 `yaml:"peak_demand"`
+
+	WeatherMultipliers    map[string]float64 `yaml:"weather_multipliers"`
+	ExpressPremiumPercent float64            `yaml:"express_premium_percent"`
+	SmallOrderThreshold   float64            `yaml:"small_order_threshold"`
+	SmallOrderFee         float64            `yaml:"small_order_fee"`
+	// ForecastWeight is the fee at ForecastPeak forecast orders per hour in the pickup zone.
+	ForecastWeight float64 `yaml:"forecast_weight"`
+	ForecastPeak   float64 `yaml:"forecast_peak"`
+	// WaiveBaseFee zeroes the base line; surge, weather and express still scale off BasePrice.
+	WaiveBaseFee bool `yaml:"waive_base_fee"`
+
+	// CartTiers reduce or waive the fee by cart subtotal. The tier with the highest min_subtotal not above
+	// the subtotal applies, after the min/max clamp.
+	CartTiers []CartTierCfg `yaml:"cart_tiers"`
+
+	MinFee float64 `yaml:"min_fee"`
+	MaxFee float64 `yaml:"max_fee"` // 0 -> no cap
+
+	// Components lists the fee components in evaluation order. Empty -> pricing.DefaultComponents.
+	Components []string `yaml:"components"`
+
+	Timezone string           `yaml:"timezone"` // IANA name used for rule time windows; empty -> local time
+	Rules    []PricingRuleCfg `yaml:"rules"`
+
+	Payout PayoutCfg `yaml:"payout"`
+}
+
+// Location is the time zone of Timezone, or local time when it is empty or unknown; NewPipeline rejects
+// unknown names.
+func (p PricingCfg) Location() *time.Location {
+	if p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// PayoutCfg is the driver payout computed alongside the customer price, in the same currency.
+type PayoutCfg struct {
+	BasePerTrip       float64        `yaml:"base_per_trip"`
+	PerKm             float64        `yaml:"per_km"`
+	PerMinute         float64        `yaml:"per_minute"`
+	AvgSpeedKmh       float64        `yaml:"avg_speed_kmh"`       // free-flow speed for trip time; traffic 1 halves it
+	SurgeSharePercent float64        `yaml:"surge_share_percent"` // of the customer's surge line
+	WaitFreeMinutes   float64        `yaml:"wait_free_minutes"`   // wait at pickup/dropoff that is not compensated
+	WaitPerMinute     float64        `yaml:"wait_per_minute"`
+	MinPayout         float64        `yaml:"min_payout"`
+	Incentives        []IncentiveCfg `yaml:"incentives"`
+}
+
+// IncentiveCfg pays Amount on top of the trip pay when every condition that is set matches.
+type IncentiveCfg struct {
+	Name   string        `yaml:"name"`
+	When   RuleCondition `yaml:"when"`
+	Amount float64       `yaml:"amount"`
+}
+
+// CartTierCfg sets exactly one of DiscountAmount, DiscountPercent or Waive.
+type CartTierCfg struct {
+	MinSubtotal     float64 `yaml:"min_subtotal"`
+	DiscountAmount  float64 `yaml:"discount_amount"`
+	DiscountPercent float64 `yaml:"discount_percent"`
+	Waive           bool    `yaml:"waive"`
+}
+
+// PricingRuleCfg is a declarative adjustment applied after the fee components, in config order.
+// A rule fires when every condition that is set matches.
+type PricingRuleCfg struct {
+	Name   string        `yaml:"name"`
+	When   RuleCondition `yaml:"when"`
+	Effect RuleEffect    `yaml:"effect"`
+}
+
+type RuleCondition struct {
+	TimeFrom      string   `yaml:"time_from"` // "HH:MM", may wrap past midnight; must differ from time_to
+	TimeTo        string   `yaml:"time_to"`
+	Weekdays      []string `yaml:"weekdays"` // "mon".."sun"
+	Priorities    []string `yaml:"priorities"`
+	Zones         []string `yaml:"zones"`
+	MinDistanceKm float64  `yaml:"min_distance_km"`
+	MaxDistanceKm float64  `yaml:"max_distance_km"`
+	MinCartValue  float64  `yaml:"min_cart_value"`
+	MaxCartValue  float64  `yaml:"max_cart_value"`
+}
+
+type RuleEffect struct {
+	Type   string  `yaml:"type"`   // add | multiply | override | cap
+	Target string  `yaml:"target"` // component name, or "total" (default)
+	Value  float64 `yaml:"value"`
+}
+
+type ZoneCfg struct {
+	CellSizeDeg    float64 `yaml:"cell_size_deg"`   // grid cell edge in degrees
+	NeighborWeight float64 `yaml:"neighbor_weight"` // weight of the 8 surrounding cells in demand smoothing; 0 -> local cell only
+}
+
+// SurgeCfg drives the background surge controller. The smoothed demand index of a zone switches surge on
+// at EnterThreshold and off below ExitThreshold; the published multiplier moves towards its target by at
+// most MaxStepPerMinute.
+type SurgeCfg struct {
+	Enabled          bool          `yaml:"enabled"`
+	Interval         time.Duration `yaml:"interval"`
+	Alpha            float64       `yaml:"alpha"` // EWMA weight of the newest sample, in (0, 1]; 0 -> default
+	EnterThreshold   float64       `yaml:"enter_threshold"`
+	ExitThreshold    float64       `yaml:"exit_threshold"`
+	MaxStepPerMinute float64       `yaml:"max_step_per_minute"`
+	TTL              time.Duration `yaml:"ttl"` // lifetime of a published multiplier in the cache
+}
+
+// WeatherCfg selects the weather feed behind the weather pricing component.
+type WeatherCfg struct {
+	Provider   string        `yaml:"provider"`    // "file", "cache" or "" for none
+	File       string        `yaml:"file"`        // JSON or CSV feed
+	CheckEvery time.Duration `yaml:"check_every"` // how often to stat the feed for changes
+	CacheTTL   time.Duration `yaml:"cache_ttl"`   // lifetime of a condition cached by the cache provider
+}
+
+// TrafficCfg governs congestion readings posted to /traffic. Readings count as fresh for TTL after
+// they were observed; a route without any fresh reading is priced at DefaultScore.
+type TrafficCfg struct {
+	TTL          time.Duration     `yaml:"ttl"`
+	DefaultScore float64           `yaml:"default_score"`
+	Profiles     TrafficProfileCfg `yaml:"profiles"`
+}
+
+// TrafficProfileCfg drives the hour-of-week congestion profiles that time route queries beyond the
+// freshness of live readings. Profiles are learned from the readings of the last History for zones and
+// edges with at least MinReadings, and File (CSV) profiles take precedence over learned ones.
+type TrafficProfileCfg struct {
+	File        string        `yaml:"file"`
+	Learn       bool          `yaml:"learn"`
+	Interval    time.Duration `yaml:"interval"`
+	History     time.Duration `yaml:"history"`
+	MinReadings int           `yaml:"min_readings"`
+}
+
+// ForecastCfg drives the hourly demand models fitted per zone. Season is in hours (24 or 168); History
+// must cover at least two seasons.
+type ForecastCfg struct {
+	Enabled       bool          `yaml:"enabled"`
+	RefitInterval time.Duration `yaml:"refit_interval"`
+	History       time.Duration `yaml:"history"`
+	Season        int           `yaml:"season"`
+	MinOrders     int           `yaml:"min_orders"` // zones with fewer orders in History get no model
+	Level         float64       `yaml:"level"`      // default prediction interval coverage
+}
+
+// ElasticityCfg drives the conversion-versus-surge estimates per zone and hour band. HourBands are the
+// local start hours of the bands, ascending from 0; pricing calls in the last Window are bucketed by
+// multiplier in steps of BucketWidth.
+type ElasticityCfg struct {
+	Enabled     bool          `yaml:"enabled"`
+	Interval    time.Duration `yaml:"interval"`
+	Window      time.Duration `yaml:"window"`
+	HourBands   []int         `yaml:"hour_bands"`
+	BucketWidth float64       `yaml:"bucket_width"`
+	MinSamples  int           `yaml:"min_samples"` // buckets with fewer calls do not count
+	Guardrail   GuardrailCfg  `yaml:"guardrail"`
+}
+
+// GuardrailCfg caps surge in a zone and hour band at the multiplier where conversion falls more than
+// MaxConversionDrop (relative) below its rate without surge.
+type GuardrailCfg struct {
+	Enabled           bool    `yaml:"enabled"`
+	MaxConversionDrop float64 `yaml:"max_conversion_drop"`
+}
+
+// RoutingCfg points at the OpenStreetMap extract the road graph is built from at startup, or the contraction
+// hierarchy `VOID contract` built from it.
+type RoutingCfg struct {
+	OSMFile       string             `yaml:"osm_file"`        // .osm (XML) or .osm.pbf; empty -> no routing
+	CHFile        string             `yaml:"ch_file"`         // loaded instead of osm_file when set
+	Algorithm     string             `yaml:"algorithm"`       // ch (default with ch_file), astar (default) or dijkstra
+	MaxSnapMeters float64            `yaml:"max_snap_meters"` // farthest a route end may be from the nearest road node
+	Speeds        map[string]float64 `yaml:"speeds"`          // km/h per highway type, over routing.DefaultSpeeds
+}
+
+type QuoteCfg struct {
+	TTL time.Duration `yaml:"ttl"` // e.g. "5m"; 0 -> DefaultQuoteTTL
 }
 
 type Config struct {
-	Server   ServerCfg  `yaml:"server"`
-	Database DBCfg      `yaml:"database"`
-	Redis    RedisCfg   `yaml:"redis"`
-	JWT      JWTCfg     `yaml:"jwt"`
-	Pricing  PricingCfg `yaml:"pricing"`
+	Server   ServerCfg   `yaml:"server"`
+	Database DBCfg       `yaml:"database"`
+	Redis    RedisCfg    `yaml:"redis"`
+	JWT      JWTCfg      `yaml:"jwt"`
+	Pricing  PricingCfg  `yaml:"pricing"`
+	Quotes   QuoteCfg    `yaml:"quotes"`
+	Zones    ZoneCfg     `yaml:"zones"`
+	Surge    SurgeCfg    `yaml:"surge"`
+	Weather  WeatherCfg  `yaml:"weather"`
+	Traffic  TrafficCfg  `yaml:"traffic"`
+	Forecast ForecastCfg `yaml:"forecast"`
+
+	Elasticity ElasticityCfg `yaml:"elasticity"`
+	Tax        TaxCfg        `yaml:"tax"`
+	Routing    RoutingCfg    `yaml:"routing"`
+
+	Experiments   []ExperimentCfg `yaml:"experiments"`
+	Subscriptions SubscriptionCfg `yaml:"subscriptions"`
+	Reload        ReloadCfg       `yaml:"reload"`
+
+	// live is the active pricing version; Pricing and Experiments above are as read from the file at startup.
+	live atomic.Pointer[PricingVersion]
+}
+
+// ReloadCfg controls how often the config file and the stored pricing versions are checked for changes.
+type ReloadCfg struct {
+	Interval time.Duration `yaml:"interval"` // 0 -> DefaultReloadInterval
+}
+
+// Path is the config file, from CONFIG_PATH or config/default.yaml.
+func Path() string {
+	if v := os.Getenv("CONFIG_PATH"); v != "" {
+		return v
+	}
+	return "config/default.yaml"
 }
 
 func LoadConfig() *Config {
-	f, err := os.Open("config/default.yaml")
+	f, err := os.Open(Path())
 	if err != nil {
 		log.Fatalf("cannot open config file: %v", err)
 	}
@@ -60,5 +278,134 @@ func LoadConfig() *Config {
 	if v := os.Getenv("JWT_SECRET"); v != "" {
 		cfg.JWT.Secret = v
 	}
+	if cfg.Zones.CellSizeDeg <= 0 {
+		cfg.Zones.CellSizeDeg = DefaultCellSizeDeg
+	}
+	if cfg.Quotes.TTL <= 0 {
+		cfg.Quotes.TTL = DefaultQuoteTTL
+	}
+	if cfg.Surge.Interval <= 0 {
+		cfg.Surge.Interval = DefaultSurgeInterval
+	}
+	if cfg.Surge.TTL <= 0 {
+		cfg.Surge.TTL = 4 * cfg.Surge.Interval
+	}
+	if cfg.Surge.Alpha == 0 {
+		cfg.Surge.Alpha = DefaultSurgeAlpha
+	}
+	if cfg.Traffic.TTL <= 0 {
+		cfg.Traffic.TTL = DefaultTrafficTTL
+	}
+	if cfg.Traffic.Profiles.Interval <= 0 {
+		cfg.Traffic.Profiles.Interval = DefaultProfileInterval
+	}
+	if cfg.Traffic.Profiles.History <= 0 {
+		cfg.Traffic.Profiles.History = DefaultProfileHistory
+	}
+	if cfg.Traffic.Profiles.MinReadings <= 0 {
+		cfg.Traffic.Profiles.MinReadings = DefaultProfileMinReadings
+	}
+	if cfg.Forecast.RefitInterval <= 0 {
+		cfg.Forecast.RefitInterval = DefaultForecastRefit
+	}
+	if cfg.Forecast.Season <= 0 {
+		cfg.Forecast.Season = DefaultForecastSeason
+	}
+	if cfg.Forecast.History <= 0 {
+		cfg.Forecast.History = 4 * time.Duration(cfg.Forecast.Season) * time.Hour
+	}
+	if cfg.Forecast.Level <= 0 || cfg.Forecast.Level >= 1 {
+		cfg.Forecast.Level = DefaultForecastLevel
+	}
+	if cfg.Elasticity.Interval <= 0 {
+		cfg.Elasticity.Interval = DefaultElasticityInterval
+	}
+	if cfg.Elasticity.Window <= 0 {
+		cfg.Elasticity.Window = DefaultElasticityWindow
+	}
+	if len(cfg.Elasticity.HourBands) == 0 {
+		cfg.Elasticity.HourBands = []int{0}
+	}
+	if cfg.Elasticity.BucketWidth <= 0 {
+		cfg.Elasticity.BucketWidth = DefaultElasticityBucket
+	}
+	if cfg.Elasticity.Guardrail.MaxConversionDrop <= 0 {
+		cfg.Elasticity.Guardrail.MaxConversionDrop = DefaultMaxConversionDrop
+	}
+	if err := validateHourBands(cfg.Elasticity.HourBands); err != nil {
+		log.Fatalf("invalid elasticity config: %v", err)
+	}
+	if cfg.Reload.Interval <= 0 {
+		cfg.Reload.Interval = DefaultReloadInterval
+	}
+	if cfg.Routing.MaxSnapMeters <= 0 {
+		cfg.Routing.MaxSnapMeters = DefaultMaxSnapMeters
+	}
+	switch cfg.Routing.Algorithm {
+	case "":
+		cfg.Routing.Algorithm = DefaultRoutingAlgorithm
+		if cfg.Routing.CHFile != "" {
+			cfg.Routing.Algorithm = "ch"
+		}
+	case "ch":
+		if cfg.Routing.CHFile == "" {
+			log.Fatalf("invalid routing config: algorithm ch needs ch_file")
+		}
+	case "astar", "dijkstra":
+	default:
+		log.Fatalf("invalid routing config: unknown algorithm %q", cfg.Routing.Algorithm)
+	}
+	if cfg.Weather.CheckEvery <= 0 {
+		cfg.Weather.CheckEvery = DefaultWeatherCheckEvery
+	}
+	if cfg.Weather.CacheTTL <= 0 {
+		cfg.Weather.CacheTTL = DefaultWeatherCacheTTL
+	}
+	if cfg.Surge.Alpha < 0 || cfg.Surge.Alpha > 1 {
+		log.Fatalf("invalid surge config: alpha %v outside (0, 1]", cfg.Surge.Alpha)
+	}
+	if cfg.Surge.ExitThreshold > cfg.Surge.EnterThreshold {
+		log.Fatalf("invalid surge config: exit_threshold above enter_threshold")
+	}
+	if err := cfg.ResolveExperiments(); err != nil {
+		log.Fatalf("invalid experiments config: %v", err)
+	}
+	if err := cfg.validateSubscriptions(); err != nil {
+		log.Fatalf("invalid subscriptions config: %v", err)
+	}
+	if err := cfg.validateTax(); err != nil {
+		log.Fatalf("invalid tax config: %v", err)
+	}
+	if cfg.Tax.Invoice.Prefix == "" {
+		cfg.Tax.Invoice.Prefix = DefaultInvoicePrefix
+	}
 	return cfg
 }
+
+// LoadPricingFile reads a YAML file with a top-level pricing section and applies it on top of base.
+// Keys missing from the file keep their base values.
+func LoadPricingFile(path string, base PricingCfg) (PricingCfg, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return PricingCfg{}, err
+	}
+	var doc struct {
+		Pricing yaml.Node `yaml:"pricing"`
+	}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return PricingCfg{}, err
+	}
+	return overridePricing(base, &doc.Pricing)
+}
+
+func validateHourBands(bands []int) error {
+	if bands[0] != 0 {
+		return errors.New("hour_bands must start at 0")
+	}
+	for i := 1; i < len(bands); i++ {
+		if bands[i] <= bands[i-1] || bands[i] > 23 {
+			return errors.New("hour_bands must be ascending hours below 24")
+		}
+	}
+	return nil
+}
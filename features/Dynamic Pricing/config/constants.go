@@ -1,8 +1,40 @@
 package config
 
+import "time"
+
 const (
 	OrderStatusPending   = "PENDING"
 	OrderStatusAssigned  = "ASSIGNED"
 	OrderStatusDelivered = "DELIVERED"
 	OrderStatusCanceled  = "CANCELED"
 )
+
+const (
+	DefaultQuoteTTL      = 5 * time.Minute
+	DefaultCellSizeDeg   = 0.005
+	DefaultSurgeInterval = 15 * time.Second
+	DefaultSurgeAlpha    = 0.3
+
+	DefaultTrafficTTL     = 10 * time.Minute
+	DefaultReloadInterval = 10 * time.Second
+
+	DefaultProfileInterval    = time.Hour
+	DefaultProfileHistory     = 28 * 24 * time.Hour
+	DefaultProfileMinReadings = 24
+
+	DefaultForecastRefit  = time.Hour
+	DefaultForecastSeason = 168 // hours in a week
+	DefaultForecastLevel  = 0.95
+
+	DefaultElasticityInterval = time.Hour
+	DefaultElasticityWindow   = 28 * 24 * time.Hour
+	DefaultElasticityBucket   = 0.25
+	DefaultMaxConversionDrop  = 0.3
+
+	DefaultInvoicePrefix    = "INV-"
+	DefaultMaxSnapMeters    = 500
+	DefaultRoutingAlgorithm = "astar"
+
+	DefaultWeatherCheckEvery = 10 * time.Second
+	DefaultWeatherCacheTTL   = 10 * time.Minute
+)
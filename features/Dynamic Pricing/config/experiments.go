@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExperimentCfg splits users between pricing variants. At most one experiment may be enabled at a time.
+type ExperimentCfg struct {
+	Name     string       `yaml:"name"`
+	Enabled  bool         `yaml:"enabled"`
+	Variants []VariantCfg `yaml:"variants"`
+}
+
+// VariantCfg overrides part of the base pricing section for the users bucketed into it.
+// Pricing holds any subset of the pricing keys; rules, if given, replace the base rule list.
+type VariantCfg struct {
+	Name    string    `yaml:"name"`
+	Weight  int       `yaml:"weight"`
+	Pricing yaml.Node `yaml:"pricing"`
+
+	// Resolved is the base pricing config with Pricing applied, filled by ResolveExperiments.
+	Resolved PricingCfg `yaml:"-"`
+}
+
+// ResolveExperiments validates the experiments section and fills every variant's Resolved pricing.
+func (c *Config) ResolveExperiments() error {
+	return resolveExperiments(c.Pricing, c.Experiments)
+}
+
+func resolveExperiments(base PricingCfg, experiments []ExperimentCfg) error {
+	enabled := 0
+	for i := range experiments {
+		e := &experiments[i]
+		if e.Name == "" {
+			return fmt.Errorf("experiment without name")
+		}
+		if e.Enabled {
+			enabled++
+		}
+		if len(e.Variants) == 0 {
+			return fmt.Errorf("experiment %s: no variants", e.Name)
+		}
+		seen := map[string]bool{}
+		for j := range e.Variants {
+			v := &e.Variants[j]
+			if v.Name == "" || seen[v.Name] {
+				return fmt.Errorf("experiment %s: variant names must be unique and non-empty", e.Name)
+			}
+			seen[v.Name] = true
+			if v.Weight <= 0 {
+				return fmt.Errorf("experiment %s: variant %s needs a positive weight", e.Name, v.Name)
+			}
+			resolved, err := overridePricing(base, &v.Pricing)
+			if err != nil {
+				return fmt.Errorf("experiment %s: variant %s: %w", e.Name, v.Name, err)
+			}
+			v.Resolved = resolved
+		}
+	}
+	if enabled > 1 {
+		return fmt.Errorf("%d experiments enabled, at most one allowed", enabled)
+	}
+	return nil
+}
+
+// ActiveExperiment returns the enabled experiment of the live pricing version, if any.
+func (c *Config) ActiveExperiment() *ExperimentCfg {
+	return c.Live().ActiveExperiment()
+}
+
+// overridePricing deep-copies base through YAML and decodes the override node on top of it.
+func overridePricing(base PricingCfg, override *yaml.Node) (PricingCfg, error) {
+	raw, err := yaml.Marshal(base)
+	if err != nil {
+		return PricingCfg{}, err
+	}
+	var out PricingCfg
+	if err := yaml.Unmarshal(raw, &out); err != nil {
+		return PricingCfg{}, err
+	}
+	if override.Kind == 0 {
+		return out, nil
+	}
+	if err := override.Decode(&out); err != nil {
+		return PricingCfg{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SubscriptionCfg defines the delivery passes users can buy. A pass set to auto-renew stays active for
+// GracePeriod after it ends, while the renewal is pending.
+type SubscriptionCfg struct {
+	GracePeriod time.Duration `yaml:"grace_period"`
+	Plans       []PlanCfg     `yaml:"plans"`
+}
+
+// PlanCfg is a subscription plan. Pricing overrides part of the pricing section for members, the same way
+// an experiment variant does, and is applied on top of whatever variant the member is bucketed into.
+type PlanCfg struct {
+	Name    string        `yaml:"name"`
+	Price   float64       `yaml:"price"` // per period, in major units of the pricing currency
+	Period  time.Duration `yaml:"period"`
+	Pricing yaml.Node     `yaml:"pricing"`
+}
+
+// MemberPricing applies the plan's pricing override on top of base.
+func (p *PlanCfg) MemberPricing(base PricingCfg) (PricingCfg, error) {
+	return overridePricing(base, &p.Pricing)
+}
+
+// Plan returns the named subscription plan, or nil.
+func (c *Config) Plan(name string) *PlanCfg {
+	for i := range c.Subscriptions.Plans {
+		if c.Subscriptions.Plans[i].Name == name {
+			return &c.Subscriptions.Plans[i]
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateSubscriptions() error {
+	seen := map[string]bool{}
+	for _, p := range c.Subscriptions.Plans {
+		if p.Name == "" || seen[p.Name] {
+			return fmt.Errorf("plan names must be unique and non-empty")
+		}
+		seen[p.Name] = true
+		if p.Period <= 0 || p.Price < 0 {
+			return fmt.Errorf("plan %s: period must be positive and price not negative", p.Name)
+		}
+	}
+	if c.Subscriptions.GracePeriod < 0 {
+		return fmt.Errorf("grace_period must not be negative")
+	}
+	return nil
+}
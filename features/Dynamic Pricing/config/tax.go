@@ -0,0 +1,45 @@
+package config
+
+import "fmt"
+
+// TaxCfg defines the GST-style taxes charged on the fee lines of an order. Each fee line is taxed by the
+// first rule matching its component and the order's item category.
+type TaxCfg struct {
+	Inclusive bool         `yaml:"inclusive"` // fees already include tax; otherwise tax is added on top
+	Rules     []TaxRuleCfg `yaml:"rules"`
+	Invoice   InvoiceCfg   `yaml:"invoice"`
+}
+
+type TaxRuleCfg struct {
+	Name       string       `yaml:"name"`
+	Components []string     `yaml:"components"` // fee components; empty -> any
+	Categories []string     `yaml:"categories"` // item categories; empty -> any
+	Taxes      []TaxRateCfg `yaml:"taxes"`      // e.g. CGST and SGST; none -> exempt
+}
+
+type TaxRateCfg struct {
+	Name string  `yaml:"name"`
+	Rate float64 `yaml:"rate"` // percent
+}
+
+// InvoiceCfg is the seller block of invoices. Numbers run per Prefix and calendar year, e.g. INV-2026-000042.
+type InvoiceCfg struct {
+	Prefix  string `yaml:"prefix"`
+	Seller  string `yaml:"seller"`
+	Address string `yaml:"address"`
+	TaxID   string `yaml:"tax_id"`
+}
+
+func (c *Config) validateTax() error {
+	for _, r := range c.Tax.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("tax rule without name")
+		}
+		for _, t := range r.Taxes {
+			if t.Name == "" || t.Rate < 0 || t.Rate > 100 {
+				return fmt.Errorf("tax rule %s: taxes need a name and a rate between 0 and 100", r.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PricingVersion is a snapshot of the reloadable part of the config: the pricing section and the
+// experiments resolved against it. Version is 0 until the snapshot has been stored.
+type PricingVersion struct {
+	Version     int
+	Pricing     PricingCfg
+	Experiments []ExperimentCfg
+}
+
+// pricingDoc is the stored form of a PricingVersion, in the same layout as the config file.
+type pricingDoc struct {
+	Pricing     PricingCfg      `yaml:"pricing"`
+	Experiments []ExperimentCfg `yaml:"experiments"`
+}
+
+// Live returns the active pricing version.
+func (c *Config) Live() *PricingVersion {
+	if v := c.live.Load(); v != nil {
+		return v
+	}
+	return &PricingVersion{Pricing: c.Pricing, Experiments: c.Experiments}
+}
+
+// Activate makes v the live pricing version. v must not be modified afterwards.
+func (c *Config) Activate(v *PricingVersion) {
+	c.live.Store(v)
+}
+
+// ActiveExperiment returns the enabled experiment, if any.
+func (v *PricingVersion) ActiveExperiment() *ExperimentCfg {
+	for i := range v.Experiments {
+		if v.Experiments[i].Enabled {
+			return &v.Experiments[i]
+		}
+	}
+	return nil
+}
+
+// ParsePricingVersion reads a document with top-level pricing and experiments sections (a config file
+// will do; other sections are ignored) and resolves its experiments.
+func ParsePricingVersion(raw []byte) (*PricingVersion, error) {
+	var doc pricingDoc
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if err := resolveExperiments(doc.Pricing, doc.Experiments); err != nil {
+		return nil, err
+	}
+	return &PricingVersion{Pricing: doc.Pricing, Experiments: doc.Experiments}, nil
+}
+
+// Override applies a document with pricing and experiments sections on top of v. Pricing keys missing
+// from the document keep their values in v; an experiments section, if given, replaces v's.
+func (v *PricingVersion) Override(raw []byte) (*PricingVersion, error) {
+	var doc struct {
+		Pricing     yaml.Node `yaml:"pricing"`
+		Experiments yaml.Node `yaml:"experiments"`
+	}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	p, err := overridePricing(v.Pricing, &doc.Pricing)
+	if err != nil {
+		return nil, err
+	}
+	experiments := &doc.Experiments
+	if experiments.Kind == 0 {
+		// Re-read the current experiments so the new version does not share variants with v.
+		raw, err := yaml.Marshal(v.Experiments)
+		if err != nil {
+			return nil, err
+		}
+		experiments = &yaml.Node{}
+		if err := yaml.Unmarshal(raw, experiments); err != nil {
+			return nil, err
+		}
+	}
+	var exps []ExperimentCfg
+	if err := experiments.Decode(&exps); err != nil {
+		return nil, err
+	}
+	if err := resolveExperiments(p, exps); err != nil {
+		return nil, err
+	}
+	return &PricingVersion{Pricing: p, Experiments: exps}, nil
+}
+
+// ReadPricingVersion parses the pricing version in the config file at path.
+func ReadPricingVersion(path string) (*PricingVersion, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePricingVersion(raw)
+}
+
+// Document renders v in canonical form, with every pricing key spelled out, and returns it with its
+// SHA-256 checksum. Equal configs render to equal documents.
+func (v *PricingVersion) Document() (string, string, error) {
+	raw, err := yaml.Marshal(pricingDoc{Pricing: v.Pricing, Experiments: v.Experiments})
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(raw)
+	return string(raw), hex.EncodeToString(sum[:]), nil
+}
+
+// Change is one leaf that differs between two pricing documents. From or To is nil when the key is absent.
+type Change struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// DiffDocuments compares two pricing documents key by key. List elements are addressed by index.
+func DiffDocuments(from, to string) ([]Change, error) {
+	var a, b interface{}
+	if err := yaml.Unmarshal([]byte(from), &a); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal([]byte(to), &b); err != nil {
+		return nil, err
+	}
+	fa, fb := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", a, fa)
+	flatten("", b, fb)
+	var out []Change
+	for k, va := range fa {
+		vb, ok := fb[k]
+		if !ok {
+			out = append(out, Change{Path: k, From: va})
+		} else if !reflect.DeepEqual(va, vb) {
+			out = append(out, Change{Path: k, From: va, To: vb})
+		}
+	}
+	for k, vb := range fb {
+		if _, ok := fa[k]; !ok {
+			out = append(out, Change{Path: k, To: vb})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, c := range t {
+			flatten(join(k), c, out)
+		}
+	case []interface{}:
+		for i, c := range t {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), c, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
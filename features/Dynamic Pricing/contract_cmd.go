@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/routing"
+)
+
+// runContract implements `VOID contract`: build the contraction hierarchy of the OSM extract, write it
+// for the server to load, and check queries on the written file against Dijkstra.
+//
+//	VOID contract -osm config/roads.osm -out config/roads.ch -verify 1000
+func runContract(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("contract", flag.ExitOnError)
+	osmFile := fs.String("osm", cfg.Routing.OSMFile, "OSM XML or .osm.pbf extract")
+	out := fs.String("out", cfg.Routing.CHFile, "hierarchy output file")
+	verify := fs.Int("verify", 1000, "random queries to check against Dijkstra; 0 skips the check")
+	seed := fs.Int64("seed", 1, "seed for the verification queries")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: VOID contract [flags]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if *osmFile == "" || *out == "" {
+		log.Fatalf("contract needs -osm and -out (or routing.osm_file and routing.ch_file)")
+	}
+
+	h, err := buildHierarchy(*osmFile, cfg.Routing.Speeds)
+	if err != nil {
+		log.Fatalf("road graph: %v", err)
+	}
+	if err := routing.WriteCH(*out, h); err != nil {
+		log.Fatalf("write %s: %v", *out, err)
+	}
+	log.Printf("wrote %s", *out)
+
+	if *verify <= 0 {
+		return
+	}
+	h, err = routing.ReadCH(*out)
+	if err != nil {
+		log.Fatalf("read back: %v", err)
+	}
+	start := time.Now()
+	if err := routing.Verify(h, *verify, *seed); err != nil {
+		log.Fatalf("verify: %v", err)
+	}
+	log.Printf("verified %d queries against Dijkstra (%v)", *verify, time.Since(start).Round(time.Millisecond))
+}
+
+// buildHierarchy loads the OSM extract with speeds and contracts it, recording what it was built from.
+func buildHierarchy(osmFile string, speeds map[string]float64) (*routing.CH, error) {
+	opts := routing.Options{Speeds: speeds}
+	source, err := routing.SourceKey(osmFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	g, err := routing.Load(osmFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("road graph: %d nodes, %d edges (%v)", len(g.Nodes), len(g.Edges), time.Since(start).Round(time.Millisecond))
+	start = time.Now()
+	h := routing.Contract(g)
+	h.Source = source
+	log.Printf("contracted: %d shortcuts (%v)", h.Shortcuts(), time.Since(start).Round(time.Millisecond))
+	return h, nil
+}
+
+// loadHierarchy reads routing.ch_file. When the file is missing, unreadable or was built from another
+// extract or speed table than routing.osm_file and routing.speeds, the hierarchy is rebuilt and the file
+// rewritten, so edits to either take effect on the next start.
+func loadHierarchy(cfg *config.Config) (*routing.CH, error) {
+	rc := cfg.Routing
+	if rc.OSMFile == "" {
+		return routing.ReadCH(rc.CHFile)
+	}
+	source, err := routing.SourceKey(rc.OSMFile, routing.Options{Speeds: rc.Speeds})
+	if err != nil {
+		log.Printf("cannot check %s against %s: %v", rc.CHFile, rc.OSMFile, err)
+		return routing.ReadCH(rc.CHFile)
+	}
+	h, err := routing.ReadCH(rc.CHFile)
+	switch {
+	case err == nil && h.Source == source:
+		return h, nil
+	case err == nil:
+		log.Printf("%s was built from another %s or routing.speeds; rebuilding it", rc.CHFile, rc.OSMFile)
+	case errors.Is(err, fs.ErrNotExist):
+		log.Printf("%s not found; building it from %s", rc.CHFile, rc.OSMFile)
+	default:
+		log.Printf("%v; rebuilding it", err)
+	}
+	if h, err = buildHierarchy(rc.OSMFile, rc.Speeds); err != nil {
+		return nil, err
+	}
+	if err := routing.WriteCH(rc.CHFile, h); err != nil {
+		log.Printf("write %s: %v; it will be rebuilt on the next start", rc.CHFile, err)
+	}
+	return h, nil
+}
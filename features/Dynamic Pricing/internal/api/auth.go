@@ -21,11 +21,53 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
 			return
 		}
-		ok, _ := services.ValidateToken(parts[1], cfg)
-		if !ok {
+		claims, err := services.ParseToken(parts[1], cfg)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
+		c.Set("role", claims["role"])
+		c.Set("uid", claims["uid"])
+		c.Set("email", claims["email"])
+		c.Next()
+	}
+}
+
+// OptionalAuth is AuthMiddleware for routes open to guests: without an Authorization header the request
+// goes on as user 0, but a header that is present must hold a valid token.
+func OptionalAuth(cfg *config.Config) gin.HandlerFunc {
+	auth := AuthMiddleware(cfg)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// currentUserID returns the uid claim set by AuthMiddleware, 0 for a guest.
+func currentUserID(c *gin.Context) uint {
+	uid, _ := c.Get("uid")
+	f, _ := uid.(float64)
+	return uint(f)
+}
+
+// ownerScope is the user an action on an order is checked against: the caller, or 0 (no check) for an admin.
+func ownerScope(c *gin.Context) uint {
+	if c.GetString("role") == "admin" {
+		return 0
+	}
+	return currentUserID(c)
+}
+
+// RequireRole must run after AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
 		c.Next()
 	}
 }
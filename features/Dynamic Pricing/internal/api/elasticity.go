@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ElasticityReport lists the conversion curves against the surge multiplier, of one zone with ?zone=.
+func ElasticityReport(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	estimates, err := services.ElasticityReport(db, c.Query("zone"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load elasticity estimates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"guardrail": gin.H{
+			"enabled":             cfg.Elasticity.Guardrail.Enabled,
+			"max_conversion_drop": cfg.Elasticity.Guardrail.MaxConversionDrop,
+		},
+		"hour_bands":   cfg.Elasticity.HourBands,
+		"bucket_width": cfg.Elasticity.BucketWidth,
+		"estimates":    estimates,
+	})
+}
+
+// EstimateElasticity refits the conversion curves now.
+func EstimateElasticity(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	fitted, err := services.EstimateElasticity(db, cfg, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not estimate elasticity"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"segments": len(fitted)})
+}
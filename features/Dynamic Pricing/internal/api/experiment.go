@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"VOID/config"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListExperiments returns the configured pricing experiments and their variant weights.
+func ListExperiments(c *gin.Context, cfg *config.Config) {
+	type variant struct {
+		Name   string `json:"name"`
+		Weight int    `json:"weight"`
+	}
+	type experiment struct {
+		Name     string    `json:"name"`
+		Enabled  bool      `json:"enabled"`
+		Variants []variant `json:"variants"`
+	}
+	out := []experiment{}
+	for _, e := range cfg.Live().Experiments {
+		ex := experiment{Name: e.Name, Enabled: e.Enabled}
+		for _, v := range e.Variants {
+			ex.Variants = append(ex.Variants, variant{Name: v.Name, Weight: v.Weight})
+		}
+		out = append(out, ex)
+	}
+	c.JSON(http.StatusOK, gin.H{"experiments": out})
+}
+
+// ExperimentReport returns average price, conversion and cancellation per variant.
+func ExperimentReport(c *gin.Context, db *gorm.DB) {
+	name := c.Param("name")
+	report, err := services.ExperimentReport(db, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not build report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"experiment": name, "variants": report})
+}
@@ -4,13 +4,14 @@ import (
 	"net/http"
 	"strconv"
 
+	"VOID/config"
 	"VOID/internal/cache"
 	"VOID/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func AssignFleet(c *gin.Context, db *gorm.DB, cache *cache.Cache) {
+func AssignFleet(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg *config.Config) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		idStr = c.Query("order_id")
@@ -20,7 +21,7 @@ func AssignFleet(c *gin.Context, db *gorm.DB, cache *cache.Cache) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
 		return
 	}
-	vehicle, err := services.AssignNearestDriver(db, uint(id))
+	vehicle, err := services.AssignNearestDriver(db, uint(id), cfg)
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/forecast"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxForecastHours = 168
+
+// ZoneForecast returns the demand forecast of a zone for the next ?hours= hours (default 24), with
+// prediction intervals at ?level= coverage (default forecast.level).
+func ZoneForecast(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if err != nil || hours <= 0 || hours > maxForecastHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be between 1 and 168"})
+		return
+	}
+	level := cfg.Forecast.Level
+	if v := c.Query("level"); v != "" {
+		if level, err = strconv.ParseFloat(v, 64); err != nil || level <= 0 || level >= 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level must be between 0 and 1"})
+			return
+		}
+	}
+	zone := c.Param("zone")
+	points, model, err := services.ZoneForecast(db, zone, hours, level, time.Now())
+	if errors.Is(err, services.ErrNoForecast) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load forecast"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"zone": zone, "level": level, "fitted_at": model.FittedAt, "forecast": points})
+}
+
+// FitForecasts refits the demand model of every zone now.
+func FitForecasts(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	fitted, err := services.FitDemandForecasts(db, cfg, time.Now())
+	if errors.Is(err, forecast.ErrInsufficientData) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "forecast.history must cover two seasons"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fit forecasts"})
+		return
+	}
+	zones := make([]string, 0, len(fitted))
+	for _, f := range fitted {
+		zones = append(zones, f.Zone)
+	}
+	c.JSON(http.StatusOK, gin.H{"zones": zones})
+}
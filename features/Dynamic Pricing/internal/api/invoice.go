@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const mimePDF = "application/pdf"
+
+// OrderInvoice returns the invoice of an order as JSON, or rendered with ?format=html|pdf (or the matching
+// Accept header). Invoices are issued on delivery; only the customer who placed the order, or an admin,
+// may read one.
+func OrderInvoice(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	format := c.Query("format")
+	switch format {
+	case "":
+		format = map[string]string{gin.MIMEHTML: "html", mimePDF: "pdf"}[c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML, mimePDF)]
+	case "json", "html", "pdf":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, html or pdf"})
+		return
+	}
+	doc, err := services.OrderInvoice(db, uint(id), ownerScope(c), cfg)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if errors.Is(err, services.ErrNotOrderOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrOrderNotInvoiceable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvoiceNotIssued) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load invoice"})
+		return
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "html":
+		if err := doc.HTML(&buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not render invoice"})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	case "pdf":
+		if err := doc.PDF(&buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not render invoice"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.Number+".pdf"))
+		c.Data(http.StatusOK, mimePDF, buf.Bytes())
+	default:
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// IssueInvoice issues the invoice of a delivered order that has none, for orders delivered before
+// invoices were issued on delivery. Issuing it again is a no-op.
+func IssueInvoice(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	err = services.IssueInvoice(db, uint(id), cfg, time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if errors.Is(err, services.ErrOrderNotInvoiceable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue invoice"})
+		return
+	}
+	OrderInvoice(c, db, cfg)
+}
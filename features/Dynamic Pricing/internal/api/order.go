@@ -1,48 +1,206 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"VOID/config"
 	"VOID/internal/cache"
 	"VOID/internal/models"
+	"VOID/internal/money"
 	"VOID/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// CreateOrder places an order for the signed-in user; member prices, experiment buckets and per-user
+// promotion limits follow the token, not the request body.
 func CreateOrder(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg *config.Config) {
 	var in struct {
-		UserID     uint    `json:"user_id"`
-		PickupLat  float64 `json:"pickup_lat"`
-		PickupLon  float64 `json:"pickup_lon"`
-		DropoffLat float64 `json:"dropoff_lat"`
-		DropoffLon float64 `json:"dropoff_lon"`
-		Priority   string  `json:"priority"`
-	}
-	if err := c.BindJSON(&in); err != nil {
+		PickupLat    float64 `json:"pickup_lat"`
+		PickupLon    float64 `json:"pickup_lon"`
+		DropoffLat   float64 `json:"dropoff_lat"`
+		DropoffLon   float64 `json:"dropoff_lon"`
+		Priority     string  `json:"priority"`
+		Category     string  `json:"category"`
+		CartSubtotal float64 `json:"cart_subtotal"`
+		QuoteID      string  `json:"quote_id"`
+		PromoCode    string  `json:"promo_code"`
+	}
+	if err := c.BindJSON(&in); err != nil || in.CartSubtotal < 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 		return
 	}
 	order := &models.Order{
-		UserID:     in.UserID,
-		PickupLat:  in.PickupLat,
-		PickupLon:  in.PickupLon,
-		DropoffLat: in.DropoffLat,
-		DropoffLon: in.DropoffLon,
-		Priority:   in.Priority,
-		Status:     config.OrderStatusPending,
-	}
-	if err := services.CreateOrder(db, order); err != nil {
+		UserID:       currentUserID(c),
+		PickupLat:    in.PickupLat,
+		PickupLon:    in.PickupLon,
+		DropoffLat:   in.DropoffLat,
+		DropoffLon:   in.DropoffLon,
+		Priority:     in.Priority,
+		Category:     in.Category,
+		CartSubtotal: majorUnits(in.CartSubtotal, cfg),
+		Status:       config.OrderStatusPending,
+	}
+	if in.QuoteID != "" {
+		if in.PromoCode != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "promo_code must be given when requesting the quote"})
+			return
+		}
+		createOrderFromQuote(c, db, order, in.QuoteID, cfg)
+		return
+	}
+	breakdown, err := services.PlaceOrder(db, order, in.PromoCode, cache, cfg)
+	if status, ok := promoErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create order"})
 		return
 	}
-	price, breakdown := services.CalculateDynamicPrice(db, order.ID, cache, cfg)
-	order.ComputedPrice = price
-	_ = db.Save(order)
 
-	c.JSON(http.StatusCreated, gin.H{"order": order, "price": price, "breakdown": breakdown})
+	c.JSON(http.StatusCreated, gin.H{"order": order, "price": breakdown.Total, "breakdown": breakdown})
+}
+
+// majorUnits converts an amount in major units of the pricing currency.
+func majorUnits(v float64, cfg *config.Config) money.Amount {
+	cur := cfg.Pricing.Currency
+	if cur == "" {
+		cur = money.DefaultCurrency
+	}
+	return money.FromFloat(v, cur, money.HalfUp)
+}
+
+func createOrderFromQuote(c *gin.Context, db *gorm.DB, order *models.Order, quoteID string, cfg *config.Config) {
+	q, err := services.VerifyQuote(quoteID, cfg)
+	if errors.Is(err, services.ErrQuoteExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ApplyQuote(order, q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.CreateOrderFromQuote(db, order, q, cfg); err != nil {
+		if errors.Is(err, services.ErrQuoteUsed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create order"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"order": order, "price": q.Breakdown.Total, "breakdown": q.Breakdown})
+}
+
+// CancelOrder cancels an order for the customer who placed it, or for an admin.
+func CancelOrder(c *gin.Context, db *gorm.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	order, err := services.CancelOrder(db, uint(id), ownerScope(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if errors.Is(err, services.ErrNotOrderOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrOrderNotCancelable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not cancel order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"order": order})
+}
+
+// DeliverOrder marks an order delivered. Only the driver of the assigned vehicle, or an admin, may;
+// wait_minutes is the wait the driver reports, up to services.MaxWaitMinutes.
+func DeliverOrder(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var in struct {
+		WaitMinutes float64 `json:"wait_minutes"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	order, ph, err := services.DeliverOrder(db, uint(id), ownerScope(c), in.WaitMinutes, cfg)
+	if errors.Is(err, services.ErrWaitTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if errors.Is(err, services.ErrNotOrderDriver) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrOrderNotAssigned) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not deliver order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"order": order, "payout": ph.Payout, "platform_margin": ph.PlatformMargin})
+}
+
+// OrderReconciliation returns the fee and payout lines of the pricing an order was placed at side by side.
+func OrderReconciliation(c *gin.Context, db *gorm.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	ph, err := services.OrderReconciliation(db, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pricing recorded for order"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load pricing"})
+		return
+	}
+	sides := map[string][]models.PricingComponent{}
+	for _, pc := range ph.Components {
+		sides[pc.Side] = append(sides[pc.Side], pc)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":       id,
+		"config_version": ph.ConfigVersion,
+		"inputs": gin.H{
+			"distance_km":  ph.DistanceKm,
+			"trip_minutes": ph.TripMinutes,
+			"wait_minutes": ph.WaitMinutes,
+			"surge":        ph.SurgeMultiplier,
+		},
+		"fee":             sides[models.SideFee],
+		"final_price":     ph.FinalPrice,
+		"payout":          sides[models.SidePayout],
+		"payout_total":    ph.Payout,
+		"payout_floored":  ph.PayoutFloored,
+		"platform_margin": ph.PlatformMargin,
+	})
 }
 
 func GetOrderStatus(c *gin.Context, db *gorm.DB) {
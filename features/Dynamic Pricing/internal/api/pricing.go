@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -11,6 +12,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// OrderPricing returns the price an order was placed at. It never re-prices.
+func OrderPricing(c *gin.Context, db *gorm.DB) {
+	id, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	breakdown, err := services.OrderPricing(db, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pricing stored for order"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"final_price": breakdown.Total, "breakdown": breakdown})
+}
+
+// CalculateDynamicPrice re-prices an order at the current inputs. The order keeps the price it was placed at.
 func CalculateDynamicPrice(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg *config.Config) {
 	idStr := c.Param("order_id")
 	id, err := strconv.Atoi(idStr)
@@ -18,6 +39,14 @@ func CalculateDynamicPrice(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
 		return
 	}
-	price, breakdown := services.CalculateDynamicPrice(db, uint(id), cache, cfg)
-	c.JSON(http.StatusOK, gin.H{"final_price": price, "breakdown": breakdown})
+	breakdown, err := services.CalculateDynamicPrice(db, uint(id), cache, cfg)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"final_price": breakdown.Total, "breakdown": breakdown})
 }
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func ListPricingVersions(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	versions, err := services.ListPricingVersions(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list versions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"live": cfg.Live().Version, "versions": versions})
+}
+
+func GetPricingVersion(c *gin.Context, db *gorm.DB) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+	row, err := services.GetPricingVersion(db, version)
+	if err != nil {
+		pricingConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": row})
+}
+
+// CreatePricingVersion takes a YAML document with pricing and/or experiments sections, in the layout of
+// the config file, applies it on top of the live version and makes the result live if it validates.
+// ?comment= is stored with it.
+func CreatePricingVersion(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	raw, err := c.GetRawData()
+	if err != nil || len(raw) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	v, err := cfg.Live().Override(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	row, err := services.RecordPricingVersion(db, v, c.GetString("email"), models.ConfigSourceAdmin, c.Query("comment"), cfg)
+	if err != nil {
+		pricingConfigError(c, err)
+		return
+	}
+	row.Document = ""
+	c.JSON(http.StatusCreated, gin.H{"version": row})
+}
+
+// DiffPricingVersions compares ?from= with ?to=; to defaults to the live version, from to the one before to.
+func DiffPricingVersions(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	to, from := cfg.Live().Version, 0
+	var err error
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+	}
+	from = to - 1
+	if v := c.Query("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+	}
+	changes, err := services.DiffPricingVersions(db, from, to)
+	if err != nil {
+		pricingConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+}
+
+func RollbackPricingVersion(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+	row, err := services.RollbackPricingVersion(db, version, c.GetString("email"), cfg)
+	if err != nil {
+		pricingConfigError(c, err)
+		return
+	}
+	row.Document = ""
+	c.JSON(http.StatusCreated, gin.H{"version": row})
+}
+
+// ReloadPricingConfig re-reads the config file now instead of waiting for the next watcher tick.
+func ReloadPricingConfig(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	if err := services.NewPricingConfigWatcher(db, cfg).Sync(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"live": cfg.Live().Version})
+}
+
+func pricingConfigError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrConfigVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPricingConfig):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "pricing config store failed"})
+	}
+}
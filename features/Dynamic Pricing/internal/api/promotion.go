@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"VOID/internal/models"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CreatePromotion(c *gin.Context, db *gorm.DB) {
+	var p models.Promotion
+	if err := c.BindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if err := services.CreatePromotion(db, &p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"promotion": p})
+}
+
+func ListPromotions(c *gin.Context, db *gorm.DB) {
+	promos, err := services.ListPromotions(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not list promotions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"promotions": promos})
+}
+
+// promoErrorStatus maps promotion validation errors to a client error status.
+func promoErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrPromoExhausted):
+		return http.StatusConflict, true
+	case errors.Is(err, services.ErrPromoNotFound),
+		errors.Is(err, services.ErrPromoInactive),
+		errors.Is(err, services.ErrPromoNotEligible):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/models"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateQuote prices a trip without creating an order. The returned quote_id can be passed to POST /orders
+// by the same user. The trip is priced for the signed-in user, or as a guest without a token.
+func CreateQuote(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg *config.Config) {
+	var in struct {
+		PickupLat    float64 `json:"pickup_lat"`
+		PickupLon    float64 `json:"pickup_lon"`
+		DropoffLat   float64 `json:"dropoff_lat"`
+		DropoffLon   float64 `json:"dropoff_lon"`
+		Priority     string  `json:"priority"`
+		Category     string  `json:"category"`
+		CartSubtotal float64 `json:"cart_subtotal"`
+		PromoCode    string  `json:"promo_code"`
+	}
+	if err := c.BindJSON(&in); err != nil || in.CartSubtotal < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	o := &models.Order{
+		UserID:       currentUserID(c),
+		PickupLat:    in.PickupLat,
+		PickupLon:    in.PickupLon,
+		DropoffLat:   in.DropoffLat,
+		DropoffLon:   in.DropoffLon,
+		Priority:     in.Priority,
+		Category:     in.Category,
+		CartSubtotal: majorUnits(in.CartSubtotal, cfg),
+	}
+	quoteID, q, err := services.CreateQuote(db, o, in.PromoCode, cache, cfg)
+	if status, ok := promoErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not price quote"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"quote_id":   quoteID,
+		"price":      q.Breakdown.Total,
+		"breakdown":  q.Breakdown,
+		"expires_at": q.ExpiresAt.Time,
+	})
+}
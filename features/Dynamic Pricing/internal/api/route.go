@@ -1,19 +1,149 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"VOID/config"
+	"VOID/internal/geo"
+	"VOID/internal/routing"
 	"VOID/internal/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func ComputeRoute(c *gin.Context) {
-	start := c.Query("start")
-	end := c.Query("end")
-	if start == "" || end == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start and end required"})
+const mimeGeoJSON = "application/geo+json"
+
+// ComputeRoute returns the fastest road route from ?from=lat,lon to ?to=lat,lon as a coordinate path.
+// ?algorithm=ch|astar|dijkstra overrides the configured search, to compare them on the same query.
+// ?depart= (RFC 3339) times the route for a departure other than now. The response's algorithm is the
+// search that ran: ch-astar for ch while traffic slows any road.
+func ComputeRoute(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	from, err1 := parseFloats(c.Query("from"), 2)
+	to, err2 := parseFloats(c.Query("to"), 2)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be lat,lon"})
+		return
+	}
+	var depart time.Time
+	if v := c.Query("depart"); v != "" {
+		var err error
+		if depart, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "depart must be an RFC 3339 time"})
+			return
+		}
+	}
+	route, err := services.ComputeOptimalRoute(db, from[0], from[1], to[0], to[1], c.Query("algorithm"), depart, cfg)
+	switch {
+	case errors.Is(err, routing.ErrUnknownAlgorithm):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoRoadGraph), errors.Is(err, services.ErrNoHierarchy):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOffRoad):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoRoute):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not compute route"})
+	default:
+		c.JSON(http.StatusOK, route)
+	}
+}
+
+// PlanTour orders the stops of a batch of deliveries from a start point:
+//
+//	{"start": {"lat":..,"lon":..}, "depart": "2026-10-19T18:00:00+05:30",
+//	 "jobs": [{"order_id": 12}, {"pickup": {"lat":..,"lon":..}, "dropoff": {"lat":..,"lon":..}}]}
+func PlanTour(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	var in struct {
+		Start  *services.LatLon `json:"start"`
+		Depart time.Time        `json:"depart"`
+		Jobs   []struct {
+			OrderID uint             `json:"order_id"`
+			Pickup  *services.LatLon `json:"pickup"`
+			Dropoff *services.LatLon `json:"dropoff"`
+		} `json:"jobs"`
+	}
+	if err := c.BindJSON(&in); err != nil || in.Start == nil || len(in.Jobs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start and at least one job are required"})
+		return
+	}
+	jobs := make([]services.TourJob, len(in.Jobs))
+	for i, j := range in.Jobs {
+		if j.OrderID == 0 && (j.Pickup == nil || j.Dropoff == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "each job needs an order_id or a pickup and dropoff"})
+			return
+		}
+		jobs[i].OrderID = j.OrderID
+		if j.OrderID == 0 {
+			jobs[i].Pickup, jobs[i].Dropoff = *j.Pickup, *j.Dropoff
+		}
+	}
+	t, err := services.PlanTour(db, *in.Start, jobs, in.Depart, cfg)
+	switch {
+	case errors.Is(err, services.ErrNoRoadGraph):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTourTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	case errors.Is(err, services.ErrOffRoad), errors.Is(err, services.ErrNoRoute):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not plan tour"})
+	default:
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// OrderRoute returns the route stored for an order when it was assigned, as JSON with the encoded
+// polyline (?format=polyline, the default) or as a GeoJSON LineString feature (?format=geojson or an
+// Accept of application/geo+json).
+func OrderRoute(c *gin.Context, db *gorm.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	format := c.Query("format")
+	switch format {
+	case "":
+		if c.NegotiateFormat(gin.MIMEJSON, mimeGeoJSON) == mimeGeoJSON {
+			format = "geojson"
+		}
+	case "polyline", "geojson":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be polyline or geojson"})
+		return
+	}
+	r, err := services.OrderRoute(db, uint(id))
+	if errors.Is(err, services.ErrNoOrderRoute) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load route"})
+		return
+	}
+	if format != "geojson" {
+		c.JSON(http.StatusOK, r)
+		return
+	}
+	path, err := geo.DecodePolyline(r.Polyline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "stored route is corrupt"})
 		return
 	}
-	path, cost := services.ComputeOptimalRoute(start, end)
-	c.JSON(http.StatusOK, gin.H{"path": path, "cost": cost})
+	c.Header("Content-Type", mimeGeoJSON)
+	c.JSON(http.StatusOK, geo.NewFeature(geo.LineString(path), map[string]interface{}{
+		"order_id":      r.OrderID,
+		"distance_m":    r.Meters,
+		"duration_s":    r.Seconds,
+		"free_flow_s":   r.FreeFlow,
+		"algorithm":     r.Algorithm,
+		"graph_version": r.GraphVersion,
+		"computed_at":   r.ComputedAt,
+	}))
 }
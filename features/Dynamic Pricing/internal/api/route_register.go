@@ -14,13 +14,49 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cacheClient *cache.Cache, manage
 	v1.POST("/register", func(c *gin.Context) { RegisterUser(c, db) })
 	v1.POST("/login", func(c *gin.Context) { LoginUser(c, db, cfg) })
 
-	v1.POST("/orders", func(c *gin.Context) { CreateOrder(c, db, cacheClient, cfg) })
+	v1.POST("/quotes", OptionalAuth(cfg), func(c *gin.Context) { CreateQuote(c, db, cacheClient, cfg) })
+	v1.POST("/orders", AuthMiddleware(cfg), func(c *gin.Context) { CreateOrder(c, db, cacheClient, cfg) })
 	v1.GET("/orders/:id/status", func(c *gin.Context) { GetOrderStatus(c, db) })
-	v1.POST("/orders/:id/assign", func(c *gin.Context) { AssignFleet(c, db, cacheClient) })
+	v1.POST("/orders/:id/assign", func(c *gin.Context) { AssignFleet(c, db, cacheClient, cfg) })
+	v1.GET("/orders/:id/route", func(c *gin.Context) { OrderRoute(c, db) })
+	v1.POST("/orders/:id/cancel", AuthMiddleware(cfg), func(c *gin.Context) { CancelOrder(c, db) })
+	v1.POST("/orders/:id/deliver", AuthMiddleware(cfg), func(c *gin.Context) { DeliverOrder(c, db, cfg) })
+	v1.GET("/orders/:id/invoice", AuthMiddleware(cfg), func(c *gin.Context) { OrderInvoice(c, db, cfg) })
 
-	v1.GET("/pricing/:order_id", func(c *gin.Context) { CalculateDynamicPrice(c, db, cacheClient, cfg) })
+	v1.GET("/pricing/:order_id", func(c *gin.Context) { OrderPricing(c, db) })
+	v1.POST("/pricing/:order_id", func(c *gin.Context) { CalculateDynamicPrice(c, db, cacheClient, cfg) })
 
-	v1.GET("/route", func(c *gin.Context) { ComputeRoute(c) })
+	v1.GET("/route", func(c *gin.Context) { ComputeRoute(c, db, cfg) })
+	v1.POST("/route/tour", func(c *gin.Context) { PlanTour(c, db, cfg) })
+	v1.POST("/traffic", AuthMiddleware(cfg), func(c *gin.Context) { IngestTraffic(c, db, cacheClient, cfg) })
+
+	v1.GET("/plans", func(c *gin.Context) { ListPlans(c, cfg) })
+	subs := v1.Group("/subscriptions", AuthMiddleware(cfg))
+	subs.POST("", func(c *gin.Context) { Subscribe(c, db, cfg) })
+	subs.GET("/me", func(c *gin.Context) { GetSubscription(c, db, cfg) })
+	subs.POST("/renew", func(c *gin.Context) { RenewSubscription(c, db, cfg) })
+	subs.POST("/cancel", func(c *gin.Context) { CancelSubscription(c, db, cfg) })
+
+	admin := v1.Group("/admin", AuthMiddleware(cfg), RequireRole("admin"))
+	admin.GET("/experiments", func(c *gin.Context) { ListExperiments(c, cfg) })
+	admin.GET("/experiments/:name/report", func(c *gin.Context) { ExperimentReport(c, db) })
+	admin.POST("/promotions", func(c *gin.Context) { CreatePromotion(c, db) })
+	admin.GET("/promotions", func(c *gin.Context) { ListPromotions(c, db) })
+	admin.GET("/pricing/versions", func(c *gin.Context) { ListPricingVersions(c, db, cfg) })
+	admin.POST("/pricing/versions", func(c *gin.Context) { CreatePricingVersion(c, db, cfg) })
+	admin.GET("/pricing/versions/:version", func(c *gin.Context) { GetPricingVersion(c, db) })
+	admin.POST("/pricing/versions/:version/rollback", func(c *gin.Context) { RollbackPricingVersion(c, db, cfg) })
+	admin.GET("/pricing/diff", func(c *gin.Context) { DiffPricingVersions(c, db, cfg) })
+	admin.POST("/pricing/reload", func(c *gin.Context) { ReloadPricingConfig(c, db, cfg) })
+	admin.GET("/pricing/surface", func(c *gin.Context) { PriceSurface(c, db, cacheClient, cfg) })
+	admin.POST("/orders/:id/invoice", func(c *gin.Context) { IssueInvoice(c, db, cfg) })
+	admin.GET("/orders/:id/reconciliation", func(c *gin.Context) { OrderReconciliation(c, db) })
+	admin.GET("/forecasts/:zone", func(c *gin.Context) { ZoneForecast(c, db, cfg) })
+	admin.POST("/forecasts/fit", func(c *gin.Context) { FitForecasts(c, db, cfg) })
+	admin.GET("/traffic/profiles", TrafficProfile)
+	admin.POST("/traffic/profiles/fit", func(c *gin.Context) { FitTrafficProfiles(c, db, cfg) })
+	admin.GET("/elasticity", func(c *gin.Context) { ElasticityReport(c, db, cfg) })
+	admin.POST("/elasticity/estimate", func(c *gin.Context) { EstimateElasticity(c, db, cfg) })
 
 	v1.GET("/ws", func(c *gin.Context) { WebSocketHandler(manager)(c.Writer, c.Request) })
 }
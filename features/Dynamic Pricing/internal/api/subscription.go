@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListPlans returns the delivery passes on sale and the pricing keys each one overrides for members.
+func ListPlans(c *gin.Context, cfg *config.Config) {
+	type plan struct {
+		Name     string                 `json:"name"`
+		Price    money.Amount           `json:"price"`
+		Period   string                 `json:"period"`
+		Benefits map[string]interface{} `json:"benefits"`
+	}
+	out := []plan{}
+	for _, p := range cfg.Subscriptions.Plans {
+		pl := plan{Name: p.Name, Price: majorUnits(p.Price, cfg), Period: p.Period.String(), Benefits: map[string]interface{}{}}
+		if p.Pricing.Kind != 0 {
+			_ = p.Pricing.Decode(&pl.Benefits)
+		}
+		out = append(out, pl)
+	}
+	c.JSON(http.StatusOK, gin.H{"plans": out, "grace_period": cfg.Subscriptions.GracePeriod.String()})
+}
+
+func Subscribe(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	var in struct {
+		Plan      string `json:"plan"`
+		AutoRenew bool   `json:"auto_renew"`
+	}
+	if err := c.BindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	s, err := services.Subscribe(db, currentUserID(c), in.Plan, in.AutoRenew, cfg)
+	switch {
+	case errors.Is(err, services.ErrUnknownPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadySubscribed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not subscribe"})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"subscription": s})
+	}
+}
+
+func GetSubscription(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	s, err := services.ActiveSubscription(db, currentUserID(c), cfg, time.Now())
+	subscriptionResponse(c, s, err)
+}
+
+func RenewSubscription(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	s, err := services.RenewSubscription(db, currentUserID(c), cfg)
+	subscriptionResponse(c, s, err)
+}
+
+func CancelSubscription(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	s, err := services.CancelSubscription(db, currentUserID(c), cfg)
+	subscriptionResponse(c, s, err)
+}
+
+func subscriptionResponse(c *gin.Context, s *models.Subscription, err error) {
+	if errors.Is(err, services.ErrNoSubscription) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not load subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscription": s})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PriceSurface returns, as a GeoJSON FeatureCollection, the price of a delivery from ?origin=lat,lon to
+// every cell of ?resolution= degrees (default the zone size) over ?bbox=west,south,east,north.
+// ?priority= prices express deliveries.
+func PriceSurface(c *gin.Context, db *gorm.DB, cacheClient *cache.Cache, cfg *config.Config) {
+	origin, err := parseFloats(c.Query("origin"), 2)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "origin must be lat,lon"})
+		return
+	}
+	bbox, err := parseFloats(c.Query("bbox"), 4)
+	if err != nil || bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bbox must be west,south,east,north"})
+		return
+	}
+	resolution := cfg.Zones.CellSizeDeg
+	if v := c.Query("resolution"); v != "" {
+		if resolution, err = strconv.ParseFloat(v, 64); err != nil || !(resolution >= services.MinSurfaceResolution) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "resolution must be at least " +
+				strconv.FormatFloat(services.MinSurfaceResolution, 'g', -1, 64) + " degrees"})
+			return
+		}
+	}
+	s := services.Surface{
+		OriginLat: origin[0], OriginLon: origin[1],
+		MinLat: bbox[1], MinLon: bbox[0], MaxLat: bbox[3], MaxLon: bbox[2],
+		CellSize: resolution,
+		Priority: c.Query("priority"),
+	}
+	fc, err := services.PriceSurface(db, s, cacheClient, cfg, time.Now())
+	if errors.Is(err, services.ErrSurfaceTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grid exceeds " + strconv.Itoa(services.MaxSurfaceCells) + " cells; use a coarser resolution"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not price surface"})
+		return
+	}
+	c.Header("Content-Type", mimeGeoJSON)
+	c.JSON(http.StatusOK, fc)
+}
+
+// parseFloats parses exactly n comma-separated finite numbers.
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, errors.New("wrong number of values")
+	}
+	out := make([]float64, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errors.New("values must be finite")
+		}
+		out[i] = v
+	}
+	return out, nil
+}
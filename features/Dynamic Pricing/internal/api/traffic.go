@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"VOID/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IngestTraffic accepts congestion readings for grid zones or road segments. A zone is the ID of a grid
+// zone, its south-west corner as "lat:lon"; a reading may give a point (lat, lon) instead, and it is
+// binned into its grid zone.
+func IngestTraffic(c *gin.Context, db *gorm.DB, cache *cache.Cache, cfg *config.Config) {
+	var in struct {
+		Readings []struct {
+			Zone       string    `json:"zone"`
+			Lat        *float64  `json:"lat"`
+			Lon        *float64  `json:"lon"`
+			Edge       string    `json:"edge"`
+			Congestion float64   `json:"congestion"`
+			ObservedAt time.Time `json:"observed_at"`
+		} `json:"readings"`
+	}
+	if err := c.BindJSON(&in); err != nil || len(in.Readings) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	readings := make([]models.TrafficReading, 0, len(in.Readings))
+	for _, r := range in.Readings {
+		zone := r.Zone
+		if zone == "" && r.Lat != nil && r.Lon != nil {
+			zone = grid.ZoneID(*r.Lat, *r.Lon)
+		}
+		readings = append(readings, models.TrafficReading{
+			Zone:       zone,
+			Edge:       r.Edge,
+			Congestion: r.Congestion,
+			ObservedAt: r.ObservedAt,
+			ReportedBy: currentUserID(c),
+		})
+	}
+	if err := services.IngestTraffic(db, readings, cache, cfg); err != nil {
+		if errors.Is(err, services.ErrInvalidReading) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not store readings"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"readings": readings})
+}
+
+var profileDays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// TrafficProfile returns the hour-of-week congestion routes use for ?zone= or ?edge=, by day and local hour.
+func TrafficProfile(c *gin.Context) {
+	zone, edge := c.Query("zone"), c.Query("edge")
+	if (zone == "") == (edge == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "give exactly one of zone or edge"})
+		return
+	}
+	p, err := services.TrafficProfile(zone, edge)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	days := make(map[string][]float64, len(profileDays))
+	for d, name := range profileDays {
+		days[name] = p[d*24 : (d+1)*24]
+	}
+	c.JSON(http.StatusOK, gin.H{"zone": zone, "edge": edge, "congestion": days})
+}
+
+// FitTrafficProfiles relearns the traffic profiles from the stored readings now.
+func FitTrafficProfiles(c *gin.Context, db *gorm.DB, cfg *config.Config) {
+	fitted, err := services.FitTrafficProfiles(db, cfg, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not fit traffic profiles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": fitted})
+}
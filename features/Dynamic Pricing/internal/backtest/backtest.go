@@ -0,0 +1,211 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+	"gorm.io/gorm"
+)
+
+// Candidate is a named pricing config to replay history under.
+type Candidate struct {
+	Name    string
+	Pricing config.PricingCfg
+}
+
+// Row is one history row re-priced under one candidate.
+type Row struct {
+	HistoryID uint         `json:"history_id"`
+	OrderID   uint         `json:"order_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	Candidate string       `json:"candidate"`
+	Original  money.Amount `json:"original"`
+	Price     money.Amount `json:"price"`
+	Delta     money.Amount `json:"delta"`
+	Floored   bool         `json:"floored"`
+	Capped    bool         `json:"capped"`
+	Converted bool         `json:"converted"` // the order was placed and not cancelled
+}
+
+// Summary aggregates the rows of one candidate, in major currency units. Revenue only counts rows
+// that became orders which were not cancelled.
+type Summary struct {
+	Candidate       string  `json:"candidate"`
+	Rows            int     `json:"rows"`
+	MeanPrice       float64 `json:"mean_price"`
+	P50Price        float64 `json:"p50_price"`
+	P95Price        float64 `json:"p95_price"`
+	MeanDelta       float64 `json:"mean_delta"`
+	P50Delta        float64 `json:"p50_delta"`
+	P95Delta        float64 `json:"p95_delta"`
+	OriginalRevenue float64 `json:"original_revenue"`
+	Revenue         float64 `json:"revenue"`
+	RevenueChange   float64 `json:"revenue_change"`
+	RevenueChangePc float64 `json:"revenue_change_pct"`
+	FloorRate       float64 `json:"floor_rate"`
+	CapRate         float64 `json:"cap_rate"`
+}
+
+type Result struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Baseline  Summary   `json:"baseline"` // the prices as originally charged
+	Summaries []Summary `json:"summaries"`
+	Rows      []Row     `json:"rows"`
+}
+
+// LoadHistory reads the PricingHistory rows created in [from, to) that orders were placed at: re-pricings
+// and quotes that were never redeemed are left out, and of rows from before outcomes were tracked only the
+// first of each order is kept.
+func LoadHistory(db *gorm.DB, from, to time.Time) ([]models.PricingHistory, error) {
+	var rows []models.PricingHistory
+	err := db.Where("created_at >= ? AND created_at < ?", from, to).
+		Where("COALESCE(outcome, '') NOT IN ?", []string{models.OutcomeRepriced, models.OutcomePending, models.OutcomeExpired}).
+		Order("created_at, id").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	seen := map[uint]bool{}
+	kept := rows[:0]
+	for _, ph := range rows {
+		if ph.OrderID != 0 {
+			if seen[ph.OrderID] {
+				continue
+			}
+			seen[ph.OrderID] = true
+		}
+		kept = append(kept, ph)
+	}
+	return kept, nil
+}
+
+// converted reports whether ph became an order that was not cancelled. Rows from before outcomes were
+// tracked count when they carry an order.
+func converted(ph models.PricingHistory) bool {
+	return ph.Outcome == models.OutcomeConverted || (ph.Outcome == "" && ph.OrderID != 0)
+}
+
+// Request rebuilds the pricing input recorded in a history row.
+func Request(ph models.PricingHistory) pricing.PricingRequest {
+	return pricing.PricingRequest{
+		OrderID:         ph.OrderID,
+		UserID:          ph.UserID,
+		Zone:            ph.Zone,
+		DistanceKm:      ph.DistanceKm,
+		TrafficScore:    ph.TrafficScore,
+		DemandIndex:     ph.DemandIndex,
+		ForecastDemand:  ph.ForecastDemand,
+		SurgeMultiplier: ph.SurgeMultiplier,
+		SurgeCap:        ph.SurgeCap,
+		Priority:        ph.Priority,
+		Weather:         ph.Weather,
+		CartValue:       ph.CartValue,
+		At:              ph.CreatedAt,
+	}
+}
+
+// Run re-prices every history row under every candidate.
+func Run(history []models.PricingHistory, candidates []Candidate) (*Result, error) {
+	res := &Result{Baseline: baseline(history)}
+	for _, cand := range candidates {
+		p, err := pricing.NewPipeline(cand.Pricing)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]Row, 0, len(history))
+		for _, ph := range history {
+			b := p.Price(Request(ph))
+			rows = append(rows, Row{
+				HistoryID: ph.ID,
+				OrderID:   ph.OrderID,
+				CreatedAt: ph.CreatedAt,
+				Candidate: cand.Name,
+				Original:  original(ph),
+				Price:     b.Total,
+				Delta:     b.Total.Sub(original(ph)),
+				Floored:   b.Floored,
+				Capped:    b.Capped,
+				Converted: converted(ph),
+			})
+		}
+		res.Summaries = append(res.Summaries, summarize(cand.Name, rows))
+		res.Rows = append(res.Rows, rows...)
+	}
+	return res, nil
+}
+
+func baseline(history []models.PricingHistory) Summary {
+	rows := make([]Row, 0, len(history))
+	for _, ph := range history {
+		rows = append(rows, Row{
+			OrderID: ph.OrderID, Original: original(ph), Price: original(ph), Floored: ph.Floored, Capped: ph.Capped,
+			Converted: converted(ph),
+		})
+	}
+	return summarize("baseline", rows)
+}
+
+// original is the regular price before promotion discounts and member savings, which candidates do not replay.
+func original(ph models.PricingHistory) money.Amount {
+	return ph.FinalPrice.Add(ph.Discount).Add(ph.MemberSavings)
+}
+
+func summarize(name string, rows []Row) Summary {
+	s := Summary{Candidate: name, Rows: len(rows)}
+	if len(rows) == 0 {
+		return s
+	}
+	prices := make([]float64, len(rows))
+	deltas := make([]float64, len(rows))
+	var floored, capped int
+	for i, r := range rows {
+		prices[i] = r.Price.Float64()
+		deltas[i] = r.Delta.Float64()
+		s.MeanPrice += prices[i]
+		s.MeanDelta += deltas[i]
+		if r.Converted {
+			s.OriginalRevenue += r.Original.Float64()
+			s.Revenue += prices[i]
+		}
+		if r.Floored {
+			floored++
+		}
+		if r.Capped {
+			capped++
+		}
+	}
+	n := float64(len(rows))
+	s.MeanPrice = round2(s.MeanPrice / n)
+	s.MeanDelta = round2(s.MeanDelta / n)
+	s.P50Price, s.P95Price = quantile(prices, 0.5), quantile(prices, 0.95)
+	s.P50Delta, s.P95Delta = quantile(deltas, 0.5), quantile(deltas, 0.95)
+	s.OriginalRevenue = round2(s.OriginalRevenue)
+	s.Revenue = round2(s.Revenue)
+	s.RevenueChange = round2(s.Revenue - s.OriginalRevenue)
+	if s.OriginalRevenue != 0 {
+		s.RevenueChangePc = round2(100 * s.RevenueChange / s.OriginalRevenue)
+	}
+	s.FloorRate = float64(floored) / n
+	s.CapRate = float64(capped) / n
+	return s
+}
+
+// quantile uses the nearest-rank method on a copy of vs.
+func quantile(vs []float64, q float64) float64 {
+	sorted := append([]float64(nil), vs...)
+	sort.Float64s(sorted)
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
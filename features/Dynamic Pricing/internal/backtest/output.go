@@ -0,0 +1,60 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+func WriteJSON(w io.Writer, res *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func WriteRowsCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"history_id", "order_id", "created_at", "candidate", "original", "price", "delta", "floored", "capped", "converted"})
+	for _, r := range rows {
+		_ = cw.Write([]string{
+			strconv.FormatUint(uint64(r.HistoryID), 10),
+			strconv.FormatUint(uint64(r.OrderID), 10),
+			r.CreatedAt.Format(time.RFC3339),
+			r.Candidate,
+			r.Original.Decimal(),
+			r.Price.Decimal(),
+			r.Delta.Decimal(),
+			strconv.FormatBool(r.Floored),
+			strconv.FormatBool(r.Capped),
+			strconv.FormatBool(r.Converted),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummaryCSV writes the baseline followed by one line per candidate.
+func WriteSummaryCSV(w io.Writer, res *Result) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"candidate", "rows", "mean_price", "p50_price", "p95_price", "mean_delta", "p50_delta", "p95_delta",
+		"original_revenue", "revenue", "revenue_change", "revenue_change_pct", "floor_rate", "cap_rate"})
+	for _, s := range append([]Summary{res.Baseline}, res.Summaries...) {
+		_ = cw.Write([]string{
+			s.Candidate,
+			strconv.Itoa(s.Rows),
+			fmtFloat(s.MeanPrice), fmtFloat(s.P50Price), fmtFloat(s.P95Price),
+			fmtFloat(s.MeanDelta), fmtFloat(s.P50Delta), fmtFloat(s.P95Delta),
+			fmtFloat(s.OriginalRevenue), fmtFloat(s.Revenue), fmtFloat(s.RevenueChange), fmtFloat(s.RevenueChangePc),
+			strconv.FormatFloat(s.FloorRate, 'f', 4, 64),
+			strconv.FormatFloat(s.CapRate, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
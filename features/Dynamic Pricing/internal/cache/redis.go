@@ -3,15 +3,30 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"VOID/config"
 	"github.com/redis/go-redis/v9"
 )
 
+// Cache wraps Redis. Without Redis it keeps values in process memory, so TTL'd signals
+// (surge multipliers and the like) still work on a single instance.
 type Cache struct {
 	RedisClient *redis.Client
 	Ctx         context.Context
+
+	mu        sync.Mutex
+	local     map[string]localEntry
+	lastSweep time.Time
+}
+
+// sweepEvery is how often Set drops expired local entries, which Get only drops for the keys it reads.
+const sweepEvery = time.Minute
+
+type localEntry struct {
+	value   string
+	expires time.Time // zero -> no expiry
 }
 
 func NewCache(cfg *config.Config) *Cache {
@@ -29,6 +44,25 @@ func NewCache(cfg *config.Config) *Cache {
 
 func (c *Cache) Set(key string, value string, ttl time.Duration) error {
 	if c.RedisClient == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.local == nil {
+			c.local = map[string]localEntry{}
+		}
+		now := time.Now()
+		if now.Sub(c.lastSweep) >= sweepEvery {
+			for k, e := range c.local {
+				if !e.expires.IsZero() && now.After(e.expires) {
+					delete(c.local, k)
+				}
+			}
+			c.lastSweep = now
+		}
+		e := localEntry{value: value}
+		if ttl > 0 {
+			e.expires = now.Add(ttl)
+		}
+		c.local[key] = e
 		return nil
 	}
 	return c.RedisClient.Set(c.Ctx, key, value, ttl).Err()
@@ -36,7 +70,17 @@ func (c *Cache) Set(key string, value string, ttl time.Duration) error {
 
 func (c *Cache) Get(key string) (string, error) {
 	if c.RedisClient == nil {
-		return "", nil
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e, ok := c.local[key]
+		if !ok {
+			return "", nil
+		}
+		if !e.expires.IsZero() && time.Now().After(e.expires) {
+			delete(c.local, key)
+			return "", nil
+		}
+		return e.value, nil
 	}
 	return c.RedisClient.Get(c.Ctx, key).Result()
 }
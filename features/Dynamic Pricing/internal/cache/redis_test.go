@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetSweepsExpiredLocalEntries(t *testing.T) {
+	c := &Cache{}
+	for _, k := range []string{"surge:a", "surge:b"} {
+		if err := c.Set(k, "1.5", time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Set("kept", "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Within sweepEvery of the last sweep the expired entries stay until read.
+	if err := c.Set("surge:c", "1.2", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.local) != 4 {
+		t.Fatalf("%d local entries before the sweep is due, want 4", len(c.local))
+	}
+	c.lastSweep = time.Now().Add(-sweepEvery)
+	if err := c.Set("surge:d", "1.1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.local) != 3 {
+		t.Errorf("%d local entries after the sweep, want 3", len(c.local))
+	}
+	if v, _ := c.Get("surge:a"); v != "" {
+		t.Errorf("expired entry read back as %q", v)
+	}
+	if v, _ := c.Get("kept"); v != "1" {
+		t.Errorf("entry without expiry read back as %q, want 1", v)
+	}
+}
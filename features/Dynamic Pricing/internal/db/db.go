@@ -24,5 +24,17 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.Order{},
 		&models.Route{},
 		&models.PricingHistory{},
+		&models.PricingComponent{},
+		&models.Promotion{},
+		&models.PromotionRedemption{},
+		&models.Subscription{},
+		&models.TrafficReading{},
+		&models.TrafficProfile{},
+		&models.DemandForecast{},
+		&models.ElasticityEstimate{},
+		&models.TaxLine{},
+		&models.Invoice{},
+		&models.InvoiceSequence{},
+		&models.PricingConfigVersion{},
 	)
 }
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"fmt"
+
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"gorm.io/gorm"
+)
+
+// moneyColumns lists the legacy float64 price columns and the money.Amount columns replacing them.
+var moneyColumns = []struct {
+	model  interface{}
+	table  string
+	legacy string
+	prefix string
+}{
+	{&models.Order{}, "orders", "computed_price", "computed_price_"},
+	{&models.PricingHistory{}, "pricing_histories", "base_price", "base_price_"},
+	{&models.PricingHistory{}, "pricing_histories", "cart_value", "cart_value_"},
+	{&models.PricingHistory{}, "pricing_histories", "subtotal", "subtotal_"},
+	{&models.PricingHistory{}, "pricing_histories", "discount", "discount_"},
+	{&models.PricingHistory{}, "pricing_histories", "final_price", "final_price_"},
+	{&models.PricingComponent{}, "pricing_components", "amount", "amount_"},
+	{&models.PromotionRedemption{}, "promotion_redemptions", "discount", "discount_"},
+}
+
+// MigrateMoney converts rows written before prices were stored in minor units: each legacy float column
+// is copied into its <prefix>minor/<prefix>currency pair, then dropped. Values are converted here rather
+// than in SQL, where ROUND works on the binary float (0.285*100 is 28.4999...) and rounds ties to even on
+// Postgres; money.FromFloat rounds the decimal the float was written as half away from zero.
+// It must run after AutoMigrate has added the new columns, and is a no-op once the legacy columns are gone.
+func MigrateMoney(db *gorm.DB, currency string) error {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	for _, c := range moneyColumns {
+		if !db.Migrator().HasColumn(c.model, c.legacy) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []struct {
+				ID    uint
+				Value float64
+			}
+			err := tx.Table(c.table).Select(fmt.Sprintf("id, %s AS value", c.legacy)).
+				Where(c.legacy + " IS NOT NULL").Scan(&rows).Error
+			if err != nil {
+				return err
+			}
+			for _, r := range rows {
+				a := money.FromFloat(r.Value, currency, money.HalfUp)
+				err := tx.Table(c.table).Where("id = ?", r.ID).
+					Updates(map[string]interface{}{c.prefix + "minor": a.Minor, c.prefix + "currency": a.Currency}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(c.model, c.legacy)
+		})
+		if err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", c.table, c.legacy, err)
+		}
+	}
+	return nil
+}
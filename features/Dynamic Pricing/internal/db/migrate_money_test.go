@@ -0,0 +1,56 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"VOID/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateMoneyRoundsHalfAwayFromZero(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "void.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	// The float column as AutoMigrate created it before prices were stored in minor units.
+	if err := db.Exec("ALTER TABLE `orders` ADD `computed_price` real").Error; err != nil {
+		t.Fatal(err)
+	}
+	legacy := map[uint]float64{}
+	for _, v := range []float64{0.285, 0.125, -0.285, 12.34} {
+		o := models.Order{UserID: 1}
+		if err := db.Create(&o).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("UPDATE orders SET computed_price = ? WHERE id = ?", v, o.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		legacy[o.ID] = v
+	}
+
+	if err := MigrateMoney(db, "INR"); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn(&models.Order{}, "computed_price") {
+		t.Error("legacy column computed_price was not dropped")
+	}
+	want := map[float64]int64{0.285: 29, 0.125: 13, -0.285: -29, 12.34: 1234}
+	var orders []models.Order
+	if err := db.Find(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range orders {
+		v := legacy[o.ID]
+		if o.ComputedPrice.Minor != want[v] || o.ComputedPrice.Currency != "INR" {
+			t.Errorf("%v migrated to %v, want %d paise", v, o.ComputedPrice, want[v])
+		}
+	}
+	if err := MigrateMoney(db, "INR"); err != nil {
+		t.Errorf("second run: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package elasticity estimates how quote conversion responds to the surge multiplier.
+package elasticity
+
+import (
+	"math"
+	"sort"
+)
+
+// Sample is one resolved pricing call: the multiplier it was priced at and whether it became an order
+// that was not cancelled.
+type Sample struct {
+	Multiplier float64
+	Converted  bool
+}
+
+// Bucket aggregates the samples with a multiplier in [Multiplier, Multiplier+width).
+type Bucket struct {
+	Multiplier float64 `json:"multiplier"`
+	Samples    int     `json:"samples"`
+	Converted  int     `json:"converted"`
+	Rate       float64 `json:"rate"`
+}
+
+// Estimate is the conversion curve of one segment (zone and hour band).
+type Estimate struct {
+	Buckets    []Bucket
+	Samples    int
+	Converted  int
+	Baseline   float64 // conversion rate of the lowest bucket, i.e. without or with little surge
+	Elasticity float64 // d ln(conversion) / d ln(multiplier) over buckets with MinSamples; 0 if not estimable
+	// Cap is the lower edge of the first bucket whose conversion is more than the allowed drop below
+	// Baseline; 0 when conversion holds up (or there is no baseline).
+	Cap float64
+}
+
+// Params controls the bucketing and the cap.
+type Params struct {
+	Width      float64 // multiplier bucket width, e.g. 0.25
+	MinSamples int     // buckets with fewer samples are reported but not used
+	MaxDrop    float64 // allowed relative drop of conversion below Baseline, e.g. 0.3
+}
+
+// Fit buckets samples by multiplier and estimates the constant elasticity of conversion with a
+// sample-weighted least-squares fit of ln(rate) against ln(multiplier).
+func Fit(samples []Sample, p Params) Estimate {
+	byIdx := map[int]*Bucket{}
+	var est Estimate
+	for _, s := range samples {
+		i := 0
+		if s.Multiplier > 1 {
+			i = int((s.Multiplier - 1) / p.Width)
+		}
+		b := byIdx[i]
+		if b == nil {
+			b = &Bucket{Multiplier: 1 + float64(i)*p.Width}
+			byIdx[i] = b
+		}
+		b.Samples++
+		est.Samples++
+		if s.Converted {
+			b.Converted++
+			est.Converted++
+		}
+	}
+	for _, b := range byIdx {
+		b.Rate = float64(b.Converted) / float64(b.Samples)
+		est.Buckets = append(est.Buckets, *b)
+	}
+	sort.Slice(est.Buckets, func(i, j int) bool { return est.Buckets[i].Multiplier < est.Buckets[j].Multiplier })
+
+	var used []Bucket
+	for _, b := range est.Buckets {
+		if b.Samples >= p.MinSamples {
+			used = append(used, b)
+		}
+	}
+	if len(used) == 0 || used[0].Multiplier != 1 {
+		return est
+	}
+	est.Baseline = used[0].Rate
+	for _, b := range used[1:] {
+		if b.Rate < est.Baseline*(1-p.MaxDrop) {
+			est.Cap = b.Multiplier
+			break
+		}
+	}
+	est.Elasticity = slope(used, p.Width)
+	return est
+}
+
+// slope fits ln(rate) = a + e*ln(m) at the bucket midpoints and returns e.
+func slope(buckets []Bucket, width float64) float64 {
+	var sw, sx, sy, sxx, sxy float64
+	n := 0
+	for _, b := range buckets {
+		if b.Converted == 0 {
+			continue
+		}
+		w := float64(b.Samples)
+		x := math.Log(b.Multiplier + width/2)
+		y := math.Log(b.Rate)
+		sw += w
+		sx += w * x
+		sy += w * y
+		sxx += w * x * x
+		sxy += w * x * y
+		n++
+	}
+	if n < 2 {
+		return 0
+	}
+	den := sw*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (sw*sxy - sx*sy) / den
+}
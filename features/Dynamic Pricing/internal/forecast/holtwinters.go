@@ -0,0 +1,127 @@
+// Package forecast fits additive Holt-Winters models to hourly demand series.
+package forecast
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInsufficientData = errors.New("need at least two full seasons of data")
+
+// Model is a fitted additive Holt-Winters model of an hourly series.
+type Model struct {
+	Season int     // season length in hours, e.g. 24 or 168
+	Alpha  float64 // level smoothing
+	Beta   float64 // trend smoothing
+	Gamma  float64 // seasonal smoothing
+	Level  float64
+	Trend  float64
+	// Seasonal holds the seasonal terms of the next Season hours: Seasonal[k] applies to LastAt+k+1 hours.
+	Seasonal []float64
+	Sigma    float64   // standard deviation of the one-step-ahead errors
+	LastAt   time.Time // start of the last observed hour
+}
+
+// Point is the forecast of one hour with a prediction interval.
+type Point struct {
+	At    time.Time `json:"at"`
+	Mean  float64   `json:"mean"`
+	Lower float64   `json:"lower"`
+	Upper float64   `json:"upper"`
+}
+
+// smoothingGrid is searched for each of alpha, beta and gamma.
+var smoothingGrid = []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.7, 0.9}
+
+// Fit fits series (hourly values, the last one for the hour starting at lastAt) with the smoothing
+// parameters that minimise the one-step-ahead squared error after the first season.
+func Fit(series []float64, season int, lastAt time.Time) (*Model, error) {
+	if season < 2 || len(series) < 2*season {
+		return nil, ErrInsufficientData
+	}
+	var best *Model
+	bestSSE := math.Inf(1)
+	for _, a := range smoothingGrid {
+		for _, b := range smoothingGrid {
+			for _, g := range smoothingGrid {
+				m, sse := run(series, season, a, b, g)
+				if sse < bestSSE {
+					best, bestSSE = m, sse
+				}
+			}
+		}
+	}
+	best.Sigma = math.Sqrt(bestSSE / float64(len(series)-season))
+	best.LastAt = lastAt
+	return best, nil
+}
+
+func run(y []float64, m int, alpha, beta, gamma float64) (*Model, float64) {
+	var first, second float64
+	for i := 0; i < m; i++ {
+		first += y[i]
+		second += y[m+i]
+	}
+	level := first / float64(m)
+	trend := (second - first) / float64(m*m)
+	seasonal := make([]float64, m)
+	for i := 0; i < m; i++ {
+		seasonal[i] = y[i] - level
+	}
+	sse := 0.0
+	for t := m; t < len(y); t++ {
+		s := seasonal[t%m]
+		e := y[t] - (level + trend + s)
+		sse += e * e
+		prevLevel := level
+		level = alpha*(y[t]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		seasonal[t%m] = gamma*(y[t]-level) + (1-gamma)*s
+	}
+	next := make([]float64, m)
+	for k := 0; k < m; k++ {
+		next[k] = seasonal[(len(y)+k)%m]
+	}
+	return &Model{Season: m, Alpha: alpha, Beta: beta, Gamma: gamma, Level: level, Trend: trend, Seasonal: next}, sse
+}
+
+// Forecast returns hours steps after LastAt, with intervals at the given coverage (e.g. 0.95).
+// Demand cannot be negative, so means and bounds are clamped at 0.
+func (m *Model) Forecast(hours int, level float64) []Point {
+	return m.forecast(1, hours, level)
+}
+
+// ForecastFrom returns the hours hours that follow now, which may be some hours after LastAt.
+func (m *Model) ForecastFrom(now time.Time, hours int, level float64) []Point {
+	skip := int(now.Truncate(time.Hour).Sub(m.LastAt) / time.Hour)
+	if skip < 0 {
+		skip = 0
+	}
+	return m.forecast(skip+1, hours, level)
+}
+
+func (m *Model) forecast(from, hours int, level float64) []Point {
+	z := math.Sqrt2 * math.Erfinv(level)
+	out := make([]Point, 0, hours)
+	// Variance of the h-step error of additive Holt-Winters: sigma² (1 + Σ_{j<h} c_j²).
+	variance := 1.0
+	for h := 1; h < from+hours; h++ {
+		if h >= from {
+			mean := m.Level + float64(h)*m.Trend + m.Seasonal[(h-1)%m.Season]
+			half := z * m.Sigma * math.Sqrt(variance)
+			out = append(out, Point{
+				At:    m.LastAt.Add(time.Duration(h) * time.Hour),
+				Mean:  math.Max(mean, 0),
+				Lower: math.Max(mean-half, 0),
+				Upper: math.Max(mean+half, 0),
+			})
+		}
+		c := m.Alpha * (1 + float64(h)*m.Beta)
+		if h%m.Season == 0 {
+			c += m.Gamma * (1 - m.Alpha)
+		}
+		variance += c * c
+	}
+	return out
+}
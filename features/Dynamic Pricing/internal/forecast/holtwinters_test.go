@@ -0,0 +1,101 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+const season = 24
+
+// demand is the noise-free value of the synthetic series at hour t: a daily cycle on a slow upward trend.
+func demand(t int) float64 {
+	return 20 + 0.01*float64(t) + 8*math.Sin(2*math.Pi*float64(t)/season)
+}
+
+func series(rng *rand.Rand, n int, noise float64) []float64 {
+	ys := make([]float64, n)
+	for t := range ys {
+		ys[t] = demand(t) + noise*rng.NormFloat64()
+	}
+	return ys
+}
+
+func TestFitTracksSeasonalMeans(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 14 * season
+	lastAt := time.Date(2026, 1, 14, 23, 0, 0, 0, time.UTC)
+	m, err := Fit(series(rng, n, 1), season, lastAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Sigma < 0.7 || m.Sigma > 1.5 {
+		t.Errorf("sigma = %.2f, want close to the noise of 1", m.Sigma)
+	}
+	points := m.Forecast(2*season, 0.9)
+	if len(points) != 2*season {
+		t.Fatalf("got %d points, want %d", len(points), 2*season)
+	}
+	for h, p := range points {
+		if want := lastAt.Add(time.Duration(h+1) * time.Hour); !p.At.Equal(want) {
+			t.Errorf("point %d at %v, want %v", h, p.At, want)
+		}
+		if want := demand(n + h); math.Abs(p.Mean-want) > 1.5 {
+			t.Errorf("hour %d: mean %.2f, want %.2f", h+1, p.Mean, want)
+		}
+		if p.Lower > p.Mean || p.Upper < p.Mean {
+			t.Errorf("hour %d: interval [%.2f, %.2f] does not contain the mean %.2f", h+1, p.Lower, p.Upper, p.Mean)
+		}
+	}
+}
+
+func TestForecastIntervalCoverage(t *testing.T) {
+	const (
+		runs   = 50
+		level  = 0.9
+		n      = 14 * season
+		future = season
+	)
+	inside, total := 0, 0
+	for run := 0; run < runs; run++ {
+		rng := rand.New(rand.NewSource(int64(run)))
+		ys := series(rng, n+future, 1)
+		m, err := Fit(ys[:n], season, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for h, p := range m.Forecast(future, level) {
+			if y := ys[n+h]; y >= p.Lower && y <= p.Upper {
+				inside++
+			}
+			total++
+		}
+	}
+	if got := float64(inside) / float64(total); got < 0.82 || got > 0.97 {
+		t.Errorf("%.0f%% intervals cover %.1f%% of the held-out hours", 100*level, 100*got)
+	}
+}
+
+func TestForecastFromSkipsElapsedHours(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	lastAt := time.Date(2026, 1, 14, 23, 0, 0, 0, time.UTC)
+	m, err := Fit(series(rng, 14*season, 1), season, lastAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ahead := m.Forecast(6, 0.9)
+	from := m.ForecastFrom(lastAt.Add(3*time.Hour+20*time.Minute), 3, 0.9)
+	for i, p := range from {
+		if p != ahead[3+i] {
+			t.Errorf("ForecastFrom point %d = %+v, want %+v", i, p, ahead[3+i])
+		}
+	}
+}
+
+func TestFitNeedsTwoSeasons(t *testing.T) {
+	if _, err := Fit(make([]float64, 2*season-1), season, time.Time{}); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("err = %v, want ErrInsufficientData", err)
+	}
+}
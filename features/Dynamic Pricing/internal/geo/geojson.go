@@ -0,0 +1,50 @@
+package geo
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection. Properties is a foreign member for
+// metadata about the collection as a whole.
+type FeatureCollection struct {
+	Type       string                 `json:"type"`
+	Features   []Feature              `json:"features"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry holds the coordinates of a Point ([lon, lat]), LineString ([][lon, lat]) or Polygon
+// ([][][lon, lat]).
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+func NewFeature(g Geometry, props map[string]interface{}) Feature {
+	return Feature{Type: "Feature", Geometry: g, Properties: props}
+}
+
+func Point(lat, lon float64) Geometry {
+	return Geometry{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// Rect is the polygon of the box between the south-west and north-east corners, counterclockwise.
+func Rect(minLat, minLon, maxLat, maxLon float64) Geometry {
+	return Geometry{Type: "Polygon", Coordinates: [][][]float64{{
+		{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+	}}}
+}
+
+// LineString is the line through [lat, lon] points.
+func LineString(path [][2]float64) Geometry {
+	coords := make([][]float64, len(path))
+	for i, p := range path {
+		coords[i] = []float64{p[1], p[0]}
+	}
+	return Geometry{Type: "LineString", Coordinates: coords}
+}
@@ -0,0 +1,90 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Grid partitions the map into square lat/lon cells of CellSize degrees (0.005° ≈ 550 m),
+// the same binning as heatmap_grid.csv.
+type Grid struct {
+	CellSize float64
+}
+
+// Cell is a grid cell addressed by its row (latitude) and column (longitude) index.
+type Cell struct {
+	Row int
+	Col int
+}
+
+func (g Grid) CellOf(lat, lon float64) Cell {
+	return Cell{
+		Row: int(math.Floor(lat / g.CellSize)),
+		Col: int(math.Floor(lon / g.CellSize)),
+	}
+}
+
+// Corner returns the south-west corner of a cell.
+func (g Grid) Corner(c Cell) (lat, lon float64) {
+	return float64(c.Row) * g.CellSize, float64(c.Col) * g.CellSize
+}
+
+// ID is the stable zone identifier of a cell: the south-west corner as "lat:lon".
+func (g Grid) ID(c Cell) string {
+	lat, lon := g.Corner(c)
+	return fmt.Sprintf("%.4f:%.4f", lat, lon)
+}
+
+// ParseID returns the cell a zone ID names. The ID may be written with any precision, but it must be the
+// south-west corner of a cell to the four decimals ID gives; any other point is not a zone ID.
+func (g Grid) ParseID(id string) (Cell, bool) {
+	a, b, ok := strings.Cut(id, ":")
+	if !ok {
+		return Cell{}, false
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err1 != nil || err2 != nil || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return Cell{}, false
+	}
+	c := Cell{Row: int(math.Round(lat / g.CellSize)), Col: int(math.Round(lon / g.CellSize))}
+	cLat, cLon := g.Corner(c)
+	if math.Abs(lat-cLat) > 0.5e-4+1e-9 || math.Abs(lon-cLon) > 0.5e-4+1e-9 {
+		return Cell{}, false
+	}
+	return c, true
+}
+
+// ZoneID is shorthand for g.ID(g.CellOf(lat, lon)).
+func (g Grid) ZoneID(lat, lon float64) string {
+	return g.ID(g.CellOf(lat, lon))
+}
+
+// Bounds returns the south-west and north-east corners of the block of cells within radius rings of c.
+func (g Grid) Bounds(c Cell, radius int) (minLat, minLon, maxLat, maxLon float64) {
+	minLat, minLon = g.Corner(Cell{Row: c.Row - radius, Col: c.Col - radius})
+	maxLat, maxLon = g.Corner(Cell{Row: c.Row + radius + 1, Col: c.Col + radius + 1})
+	return
+}
+
+// LineZones returns the IDs of the cells crossed by the straight segment between two points, in order
+// from the first point. The segment is sampled every half cell, so corner-clipped cells may be skipped.
+func (g Grid) LineZones(lat1, lon1, lat2, lon2 float64) []string {
+	steps := int(math.Ceil(math.Max(math.Abs(lat2-lat1), math.Abs(lon2-lon1)) / (g.CellSize / 2)))
+	seen := map[Cell]bool{}
+	var out []string
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		c := g.CellOf(lat1+(lat2-lat1)*t, lon1+(lon2-lon1)*t)
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, g.ID(c))
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package geo
+
+import "testing"
+
+func TestGridParseID(t *testing.T) {
+	g := Grid{CellSize: 0.005}
+	tests := []struct {
+		id   string
+		want string // "" when the ID is rejected
+	}{
+		{"12.9600:77.5800", "12.9600:77.5800"},
+		{"12.96:77.58", "12.9600:77.5800"},
+		{"12.9650: 77.5850", "12.9650:77.5850"},
+		{"-0.0050:-0.0100", "-0.0050:-0.0100"},
+		{"12.9612:77.5800", ""}, // inside a cell, not its corner
+		{"12.9600", ""},
+		{"north:77.5800", ""},
+		{"95.0000:77.5800", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		c, ok := g.ParseID(tt.id)
+		got := ""
+		if ok {
+			got = g.ID(c)
+		}
+		if got != tt.want {
+			t.Errorf("ParseID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	// A cell size the four decimals of an ID round still reads back as the same cell.
+	g = Grid{CellSize: 1.0 / 300}
+	for _, c := range []Cell{{Row: 3891, Col: 23274}, {Row: -1, Col: -2}} {
+		if got, ok := g.ParseID(g.ID(c)); !ok || got != c {
+			t.Errorf("ParseID(ID(%v)) = %v, %t", c, got, ok)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+// polylineScale is the precision of encoded polylines: five decimal places, about a meter.
+const polylineScale = 1e5
+
+// EncodePolyline encodes [lat, lon] points in Google's encoded polyline format: each coordinate as the
+// zigzag-coded difference from the previous point, in 5-bit chunks offset into printable ASCII.
+func EncodePolyline(path [][2]float64) string {
+	var b strings.Builder
+	var prevLat, prevLon int64
+	for _, p := range path {
+		lat, lon := int64(math.Round(p[0]*polylineScale)), int64(math.Round(p[1]*polylineScale))
+		encodeDelta(&b, lat-prevLat)
+		encodeDelta(&b, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return b.String()
+}
+
+func encodeDelta(b *strings.Builder, d int64) {
+	v := uint64(d) << 1
+	if d < 0 {
+		v = ^v
+	}
+	for v >= 0x20 {
+		b.WriteByte(byte(0x20|v&0x1f) + 63)
+		v >>= 5
+	}
+	b.WriteByte(byte(v) + 63)
+}
+
+// DecodePolyline decodes an encoded polyline into [lat, lon] points. It fails with ErrInvalidPolyline on
+// characters outside '?'..'~', a value cut short, a latitude without its longitude or a value too long
+// for 64 bits.
+func DecodePolyline(s string) ([][2]float64, error) {
+	var path [][2]float64
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		var d [2]int64
+		for k := range d {
+			var v uint64
+			for shift := uint(0); ; shift += 5 {
+				if i >= len(s) || s[i] < 63 || s[i] > 126 || shift > 60 {
+					return nil, ErrInvalidPolyline
+				}
+				c := uint64(s[i] - 63)
+				i++
+				v |= (c & 0x1f) << shift
+				if c < 0x20 {
+					break
+				}
+			}
+			d[k] = int64(v >> 1)
+			if v&1 != 0 {
+				d[k] = ^d[k]
+			}
+		}
+		lat += d[0]
+		lon += d[1]
+		path = append(path, [2]float64{float64(lat) / polylineScale, float64(lon) / polylineScale})
+	}
+	return path, nil
+}
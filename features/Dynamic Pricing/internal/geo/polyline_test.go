@@ -0,0 +1,97 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// googleExample is the example of Google's polyline format documentation.
+const googleExample = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+var googlePath = [][2]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+
+func samePath(a, b [][2]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i][0]-b[i][0]) > 1e-9 || math.Abs(a[i][1]-b[i][1]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncodePolylineGoogleExample(t *testing.T) {
+	if got := EncodePolyline(googlePath); got != googleExample {
+		t.Errorf("EncodePolyline = %q, want %q", got, googleExample)
+	}
+}
+
+func TestDecodePolylineGoogleExample(t *testing.T) {
+	got, err := DecodePolyline(googleExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePath(got, googlePath) {
+		t.Errorf("DecodePolyline = %v, want %v", got, googlePath)
+	}
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tests := [][][2]float64{
+		nil,
+		{{0, 0}},
+		{{12.97194, 77.59369}, {12.97194, 77.59369}},
+		{{90, 180}, {-90, -180}, {0.00001, -0.00001}},
+	}
+	var walk [][2]float64
+	for i := 0; i < 500; i++ {
+		walk = append(walk, [2]float64{
+			math.Round((12.9+rnd.Float64()*0.1)*1e5) / 1e5,
+			math.Round((77.5+rnd.Float64()*0.1)*1e5) / 1e5,
+		})
+	}
+	tests = append(tests, walk)
+	for _, path := range tests {
+		got, err := DecodePolyline(EncodePolyline(path))
+		if err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		if !samePath(got, path) {
+			t.Errorf("round trip of %v gave %v", path, got)
+		}
+	}
+}
+
+func TestEncodePolylineRoundsToFiveDecimals(t *testing.T) {
+	got, err := DecodePolyline(EncodePolyline([][2]float64{{12.345674, -77.123456}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]float64{{12.34567, -77.12346}}; !samePath(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodePolylineRejectsMalformedInput(t *testing.T) {
+	for name, s := range map[string]string{
+		"value cut short":       googleExample[:len(googleExample)-1],
+		"latitude alone":        "_p~iF",
+		"character below '?'":   "_p~iF ~ps|U",
+		"character above '~'":   "_p~iF\x7f?",
+		"non-ASCII":             "_p~iFé?",
+		"value beyond 64 bits":  "~~~~~~~~~~~~~~?",
+		"trailing continuation": "??_",
+	} {
+		if got, err := DecodePolyline(s); !errors.Is(err, ErrInvalidPolyline) {
+			t.Errorf("%s: DecodePolyline(%q) = %v, %v; want ErrInvalidPolyline", name, s, got, err)
+		}
+	}
+	if got, err := DecodePolyline(""); err != nil || len(got) != 0 {
+		t.Errorf(`DecodePolyline("") = %v, %v; want no points`, got, err)
+	}
+}
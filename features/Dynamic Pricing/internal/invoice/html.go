@@ -0,0 +1,49 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{"label": label}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+tr.total td { font-weight: bold; border-top: 2px solid #222; }
+</style>
+</head>
+<body>
+<h1>Tax invoice {{.Number}}</h1>
+<p>Issued {{.IssuedAt.Format "2006-01-02"}} &middot; Order #{{.OrderID}} placed {{.OrderedAt.Format "2006-01-02 15:04"}}{{if .Category}} &middot; {{.Category}}{{end}}</p>
+<table>
+<tr><th>Seller</th><th>Billed to</th></tr>
+<tr>
+<td>{{.Seller.Name}}<br>{{.Seller.Address}}{{if .Seller.TaxID}}<br>Tax ID {{.Seller.TaxID}}{{end}}</td>
+<td>{{.Customer.Name}}<br>{{.Customer.Address}}</td>
+</tr>
+</table>
+<table>
+<tr><th>Description</th><th class="num">Amount ({{.Total.Currency}})</th></tr>
+{{range .Items}}<tr><td>{{label .Component}}</td><td class="num">{{.Amount.Decimal}}</td></tr>
+{{end}}<tr><td>Subtotal{{if .Inclusive}} (incl. tax){{end}}</td><td class="num">{{.Subtotal.Decimal}}</td></tr>
+</table>
+<table>
+<tr><th>Tax</th><th class="num">Rate</th><th class="num">Taxable value</th><th class="num">Tax</th></tr>
+{{range .Taxes}}<tr><td>{{.Tax}}</td><td class="num">{{.Rate}}%</td><td class="num">{{.Taxable.Decimal}}</td><td class="num">{{.Amount.Decimal}}</td></tr>
+{{end}}<tr><td colspan="3">Total tax</td><td class="num">{{.Tax.Decimal}}</td></tr>
+<tr class="total"><td colspan="3">Total</td><td class="num">{{.Total}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// HTML writes d as a standalone HTML page.
+func (d *Document) HTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, d)
+}
@@ -0,0 +1,82 @@
+// Package invoice renders order invoices as HTML and PDF.
+package invoice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"VOID/internal/models"
+	"VOID/internal/money"
+)
+
+type Party struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	TaxID   string `json:"tax_id,omitempty"`
+}
+
+// Item is one fee line of the invoiced order.
+type Item struct {
+	Component string       `json:"component"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// TaxSummary totals one tax at one rate over all lines.
+type TaxSummary struct {
+	Tax     string       `json:"tax"`
+	Rate    float64      `json:"rate"`
+	Taxable money.Amount `json:"taxable"`
+	Amount  money.Amount `json:"amount"`
+}
+
+// Document is everything printed on an invoice.
+type Document struct {
+	Number    string           `json:"number"`
+	IssuedAt  time.Time        `json:"issued_at"`
+	Seller    Party            `json:"seller"`
+	Customer  Party            `json:"customer"`
+	OrderID   uint             `json:"order_id"`
+	OrderedAt time.Time        `json:"ordered_at"`
+	Category  string           `json:"category,omitempty"`
+	Items     []Item           `json:"items"`
+	TaxLines  []models.TaxLine `json:"tax_lines"`
+	Taxes     []TaxSummary     `json:"taxes"`
+	Inclusive bool             `json:"inclusive"` // item amounts include tax
+	Subtotal  money.Amount     `json:"subtotal"`
+	Tax       money.Amount     `json:"tax"`
+	Total     money.Amount     `json:"total"`
+}
+
+// Summarize fills d.Taxes from d.TaxLines, ordered by tax name and rate.
+func (d *Document) Summarize() {
+	idx := map[string]int{}
+	d.Taxes = nil
+	for _, l := range d.TaxLines {
+		k := fmt.Sprintf("%s@%g", l.Tax, l.Rate)
+		i, ok := idx[k]
+		if !ok {
+			i = len(d.Taxes)
+			idx[k] = i
+			d.Taxes = append(d.Taxes, TaxSummary{Tax: l.Tax, Rate: l.Rate, Taxable: money.New(0, l.Amount.Currency), Amount: money.New(0, l.Amount.Currency)})
+		}
+		d.Taxes[i].Taxable = d.Taxes[i].Taxable.Add(l.Taxable)
+		d.Taxes[i].Amount = d.Taxes[i].Amount.Add(l.Amount)
+	}
+	sort.SliceStable(d.Taxes, func(i, j int) bool {
+		if d.Taxes[i].Tax != d.Taxes[j].Tax {
+			return d.Taxes[i].Tax < d.Taxes[j].Tax
+		}
+		return d.Taxes[i].Rate < d.Taxes[j].Rate
+	})
+}
+
+// label turns a component name into an invoice description, e.g. "small_order" -> "Small order".
+func label(component string) string {
+	s := strings.ReplaceAll(component, "_", " ")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,138 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points, with a Courier text block so columns line up without font metrics.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 50
+	fontSize     = 10
+	leading      = 13
+	linesPerPage = (pageHeight - 2*margin) / leading
+	lineWidth    = 80 // characters of Courier 10pt between the margins
+)
+
+// PDF writes d as a plain-text PDF document.
+func (d *Document) PDF(w io.Writer) error {
+	_, err := w.Write(renderPDF(d.textLines()))
+	return err
+}
+
+func (d *Document) textLines() []string {
+	cur := d.Total.Currency
+	row := func(left, right string) string {
+		pad := lineWidth - len(left) - len(right)
+		if pad < 1 {
+			pad = 1
+		}
+		return left + strings.Repeat(" ", pad) + right
+	}
+	rule := strings.Repeat("-", lineWidth)
+	lines := []string{
+		"TAX INVOICE " + d.Number,
+		"Issued " + d.IssuedAt.Format("2006-01-02"),
+		"",
+		d.Seller.Name,
+		d.Seller.Address,
+	}
+	if d.Seller.TaxID != "" {
+		lines = append(lines, "Tax ID "+d.Seller.TaxID)
+	}
+	lines = append(lines, "", "Billed to: "+d.Customer.Name)
+	if d.Customer.Address != "" {
+		lines = append(lines, d.Customer.Address)
+	}
+	order := fmt.Sprintf("Order #%d placed %s", d.OrderID, d.OrderedAt.Format("2006-01-02 15:04"))
+	if d.Category != "" {
+		order += ", " + d.Category
+	}
+	lines = append(lines, order, "", row("Description", "Amount ("+cur+")"), rule)
+	for _, it := range d.Items {
+		lines = append(lines, row(label(it.Component), it.Amount.Decimal()))
+	}
+	subtotal := "Subtotal"
+	if d.Inclusive {
+		subtotal += " (incl. tax)"
+	}
+	lines = append(lines, rule, row(subtotal, d.Subtotal.Decimal()), "",
+		row("Tax", fmt.Sprintf("%8s %14s %12s", "Rate", "Taxable value", "Tax")), rule)
+	for _, t := range d.Taxes {
+		lines = append(lines, row(t.Tax, fmt.Sprintf("%7g%% %14s %12s", t.Rate, t.Taxable.Decimal(), t.Amount.Decimal())))
+	}
+	lines = append(lines, rule, row("Total tax", d.Tax.Decimal()), row("TOTAL", d.Total.String()))
+	return lines
+}
+
+// renderPDF lays lines out top to bottom over as many pages as needed.
+func renderPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content stream per page.
+	var objs []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objs = append(objs,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, leading, margin, pageHeight-margin)
+		for _, l := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(l))
+		}
+		content.WriteString("ET")
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a PDF string literal; characters outside printable ASCII become '?', as the
+// standard Courier font has no glyphs for them.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"VOID/internal/elasticity"
+)
+
+// ElasticityEstimate is the conversion curve against the surge multiplier of one zone and hour band.
+type ElasticityEstimate struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	Zone        string              `gorm:"uniqueIndex:idx_elasticity_segment;size:32" json:"zone"`
+	Band        string              `gorm:"uniqueIndex:idx_elasticity_segment;size:8" json:"band"` // "HH-HH", local time
+	Samples     int                 `json:"samples"`
+	Converted   int                 `json:"converted"`
+	Baseline    float64             `json:"baseline"` // conversion rate without surge
+	Elasticity  float64             `json:"elasticity"`
+	SurgeCap    float64             `json:"surge_cap"` // multiplier where conversion drops past the threshold; 0 -> none
+	Buckets     []elasticity.Bucket `gorm:"serializer:json" json:"buckets"`
+	WindowStart time.Time           `json:"window_start"`
+	FittedAt    time.Time           `json:"fitted_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// DemandForecast is the stored Holt-Winters model of hourly order counts in one zone.
+type DemandForecast struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Zone         string    `gorm:"uniqueIndex;size:32" json:"zone"`
+	Season       int       `json:"season"`
+	Alpha        float64   `json:"alpha"`
+	Beta         float64   `json:"beta"`
+	Gamma        float64   `json:"gamma"`
+	Level        float64   `json:"level"`
+	Trend        float64   `json:"trend"`
+	Seasonal     []float64 `gorm:"serializer:json" json:"seasonal"`
+	Sigma        float64   `json:"sigma"`
+	LastAt       time.Time `json:"last_at"`      // start of the last hour the model was fitted on
+	Observations int       `json:"observations"` // orders in the fitting window
+	FittedAt     time.Time `json:"fitted_at"`
+}
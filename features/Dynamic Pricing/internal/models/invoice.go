@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"VOID/internal/money"
+)
+
+// TaxLine is one tax charged on one fee line of an order.
+type TaxLine struct {
+	ID        uint         `gorm:"primaryKey" json:"-"`
+	OrderID   uint         `gorm:"index" json:"-"`
+	Position  int          `json:"-"`
+	Component string       `gorm:"size:50" json:"component"`
+	Tax       string       `gorm:"size:32" json:"tax"`
+	Rate      float64      `json:"rate"` // percent
+	Taxable   money.Amount `gorm:"embedded;embeddedPrefix:taxable_" json:"taxable"`
+	Amount    money.Amount `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+}
+
+// Invoice is the numbered invoice of an order, issued once.
+type Invoice struct {
+	ID        uint         `gorm:"primaryKey" json:"-"`
+	Number    string       `gorm:"uniqueIndex;size:32" json:"number"`
+	OrderID   uint         `gorm:"uniqueIndex" json:"order_id"`
+	Subtotal  money.Amount `gorm:"embedded;embeddedPrefix:subtotal_" json:"subtotal"` // the order's fee
+	Tax       money.Amount `gorm:"embedded;embeddedPrefix:tax_" json:"tax"`
+	Total     money.Amount `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+	Inclusive bool         `json:"inclusive"` // Subtotal already includes Tax
+	IssuedAt  time.Time    `json:"issued_at"`
+}
+
+// InvoiceSequence holds the last invoice number issued in a series (prefix and year).
+type InvoiceSequence struct {
+	Series string `gorm:"primaryKey;size:32"`
+	Last   int
+}
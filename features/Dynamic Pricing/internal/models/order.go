@@ -1,6 +1,9 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"VOID/internal/money"
+	"gorm.io/gorm"
+)
 
 type Order struct {
 	gorm.Model
@@ -10,8 +13,14 @@ type Order struct {
 	DropoffLat    float64
 	DropoffLon    float64
 	Priority      string // "normal" or "express"
+	Category      string `gorm:"size:32"` // item category, selects the tax rule
 	Status        string
 	AssignedToID  *uint
 	DistanceKm    float64
-	ComputedPrice float64
+	CartSubtotal  money.Amount `gorm:"embedded;embeddedPrefix:cart_subtotal_"`
+	ComputedPrice money.Amount `gorm:"embedded;embeddedPrefix:computed_price_"`
+	Tax           money.Amount `gorm:"embedded;embeddedPrefix:tax_"` // sum of the order's TaxLines
+	DriverPayout  money.Amount `gorm:"embedded;embeddedPrefix:driver_payout_"`
+	WaitMinutes   float64      // driver wait reported on delivery
+	PromoCode     string
 }
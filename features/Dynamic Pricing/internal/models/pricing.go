@@ -1,14 +1,74 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"VOID/internal/money"
+)
 
 type PricingHistory struct {
-	ID           uint `gorm:"primaryKey"`
-	OrderID      uint
-	BasePrice    float64
-	DistanceKm   float64
-	TrafficScore float64
-	DemandIndex  float64
-	FinalPrice   float64
-	CreatedAt    time.Time
+	ID              uint `gorm:"primaryKey"`
+	OrderID         uint
+	QuoteID         string `gorm:"index;size:64"` // set when the price was issued as a quote
+	UserID          uint
+	ConfigVersion   int          `gorm:"index"` // pricing config version; 0 -> the config file, before versions were stored
+	Experiment      string       `gorm:"index;size:64"`
+	Variant         string       `gorm:"size:64"`
+	Zone            string       `gorm:"index;size:32"`
+	BasePrice       money.Amount `gorm:"embedded;embeddedPrefix:base_price_"`
+	DistanceKm      float64
+	TrafficScore    float64
+	DemandIndex     float64
+	ForecastDemand  float64 // expected orders in the zone over the next hour
+	SurgeMultiplier float64 // as published by the surge controller; 0 when pricing fell back to DemandIndex
+	PriceMultiplier float64 // surge multiplier the price was computed at, 1 without surge
+	SurgeCap        float64 // conversion guardrail in force; 0 -> none
+	Priority        string
+	Weather         string
+	CartValue       money.Amount `gorm:"embedded;embeddedPrefix:cart_value_"`
+	TripMinutes     float64
+	WaitMinutes     float64
+	Subtotal        money.Amount `gorm:"embedded;embeddedPrefix:subtotal_"` // sum of components before the min/max clamp
+	Floored         bool
+	Capped          bool
+	Rules           string       // comma-separated names of the pricing rules that fired
+	Plan            string       `gorm:"size:64"` // subscription plan of a member price
+	MemberSavings   money.Amount `gorm:"embedded;embeddedPrefix:member_savings_"`
+	PromoCode       string
+	Discount        money.Amount `gorm:"embedded;embeddedPrefix:discount_"` // promotion discount, already taken off FinalPrice
+	FinalPrice      money.Amount `gorm:"embedded;embeddedPrefix:final_price_"`
+	Payout          money.Amount `gorm:"embedded;embeddedPrefix:payout_"` // driver payout of the same inputs
+	PayoutFloored   bool
+	PlatformMargin  money.Amount       `gorm:"embedded;embeddedPrefix:platform_margin_"` // FinalPrice - Payout
+	Components      []PricingComponent `gorm:"foreignKey:PricingHistoryID"`
+	Outcome         string             `gorm:"index;size:16"` // see Outcome*; "" for rows from before outcomes were tracked
+	ExpiresAt       *time.Time         // end of a quote's validity
+	OutcomeAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// Outcomes of a pricing call.
+const (
+	OutcomePending   = "pending"   // quote not redeemed yet
+	OutcomeConverted = "converted" // became an order
+	OutcomeCanceled  = "canceled"  // became an order that was cancelled
+	OutcomeExpired   = "expired"   // quote expired unredeemed
+	OutcomeRepriced  = "repriced"  // re-pricing of an existing order; not a conversion opportunity
+)
+
+const (
+	SideFee    = "fee"
+	SidePayout = "payout"
+	SideMargin = "margin"
+)
+
+// PricingComponent is one itemised line of a PricingHistory row: a customer fee line, a driver payout
+// line or the platform margin.
+type PricingComponent struct {
+	ID               uint   `gorm:"primaryKey"`
+	PricingHistoryID uint   `gorm:"index"`
+	Side             string `gorm:"size:10;default:fee"`
+	Position         int
+	Name             string       `gorm:"size:50"`
+	Amount           money.Amount `gorm:"embedded;embeddedPrefix:amount_"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	ConfigSourceFile     = "file"
+	ConfigSourceAdmin    = "admin"
+	ConfigSourceRollback = "rollback"
+)
+
+// PricingConfigVersion is one stored revision of the pricing and experiments config. Versions are
+// append-only; the highest one is live, and a rollback stores an older document as a new version.
+type PricingConfigVersion struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Version  int    `gorm:"uniqueIndex" json:"version"`
+	Author   string `gorm:"size:100" json:"author"`
+	Source   string `gorm:"size:20" json:"source"` // file, admin or rollback
+	Comment  string `json:"comment,omitempty"`
+	Document string `json:"document,omitempty"` // canonical YAML, see config.PricingVersion.Document
+	Checksum string `gorm:"size:64" json:"checksum"`
+	// RolledBackFrom is the version whose document a rollback restored.
+	RolledBackFrom int       `json:"rolled_back_from,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"VOID/internal/money"
+	"gorm.io/gorm"
+)
+
+const (
+	PromoTypeFlat         = "flat"
+	PromoTypePercent      = "percent"
+	PromoTypeFreeDelivery = "free_delivery"
+
+	RedemptionReserved = "reserved"
+	RedemptionReleased = "released"
+)
+
+type Promotion struct {
+	gorm.Model
+	Code           string    `gorm:"uniqueIndex;size:40" json:"code"`
+	Type           string    `json:"type"`         // "flat", "percent" or "free_delivery"
+	Value          float64   `json:"value"`        // currency amount for flat, percentage for percent
+	MaxDiscount    float64   `json:"max_discount"` // cap for percent promotions; 0 -> uncapped
+	ValidFrom      time.Time `json:"valid_from"`
+	ValidTo        time.Time `json:"valid_to"`
+	MaxRedemptions int       `json:"max_redemptions"` // 0 -> unlimited
+	PerUserLimit   int       `json:"per_user_limit"`  // 0 -> unlimited
+	MinCartValue   float64   `json:"min_cart_value"`
+	Zones          string    `json:"zones"`      // comma-separated zone IDs; empty -> all
+	Priorities     string    `json:"priorities"` // comma-separated; empty -> all
+}
+
+// PromotionRedemption reserves one use of a promotion for an order, or for a quote until it expires.
+type PromotionRedemption struct {
+	ID          uint   `gorm:"primaryKey"`
+	PromotionID uint   `gorm:"index"`
+	UserID      uint   `gorm:"index"`
+	OrderID     uint   `gorm:"index"`
+	QuoteID     string `gorm:"index;size:64"`
+	Status      string
+	Discount    money.Amount `gorm:"embedded;embeddedPrefix:discount_"`
+	ExpiresAt   *time.Time   // set while only a quote holds the reservation
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -1,10 +1,20 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Route is the road route from an order's pickup to its dropoff, computed when the order was assigned.
 type Route struct {
 	gorm.Model
-	OrderID    uint
-	Path       string // simple representation (JSON/WKT) for demo
-	TravelCost float64
+	OrderID      uint      `gorm:"uniqueIndex" json:"order_id"`
+	Polyline     string    `gorm:"type:text" json:"polyline"` // Google encoded polyline of the path
+	Meters       float64   `json:"distance_m"`
+	Seconds      float64   `json:"duration_s"`  // with congestion at ComputedAt
+	FreeFlow     float64   `json:"free_flow_s"` // without congestion
+	Algorithm    string    `gorm:"size:16" json:"algorithm"`
+	GraphVersion string    `gorm:"size:32" json:"graph_version"` // routing.Graph.Version the path is on
+	ComputedAt   time.Time `json:"computed_at"`
 }
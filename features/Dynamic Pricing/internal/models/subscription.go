@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"VOID/internal/money"
+	"gorm.io/gorm"
+)
+
+// Subscription is a user's delivery pass. See config.SubscriptionCfg for the plans and grace period.
+type Subscription struct {
+	gorm.Model
+	UserID     uint         `gorm:"index" json:"user_id"`
+	User       User         `json:"-"`
+	Plan       string       `gorm:"size:64" json:"plan"`
+	Price      money.Amount `gorm:"embedded;embeddedPrefix:price_" json:"price"` // charged per period
+	StartsAt   time.Time    `json:"starts_at"`
+	EndsAt     time.Time    `gorm:"index" json:"ends_at"`
+	AutoRenew  bool         `json:"auto_renew"`
+	CanceledAt *time.Time   `json:"canceled_at,omitempty"` // auto-renew switched off; the pass runs to EndsAt
+}
+
+// ActiveAt reports whether s gives member pricing at t.
+func (s *Subscription) ActiveAt(t time.Time, grace time.Duration) bool {
+	if t.Before(s.StartsAt) {
+		return false
+	}
+	if t.Before(s.EndsAt) {
+		return true
+	}
+	return s.AutoRenew && t.Before(s.EndsAt.Add(grace))
+}
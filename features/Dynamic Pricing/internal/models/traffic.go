@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TrafficReading is one congestion observation for a grid zone or a road segment (exactly one is set).
+type TrafficReading struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Zone       string    `gorm:"index;size:32" json:"zone,omitempty"`
+	Edge       string    `gorm:"index;size:64" json:"edge,omitempty"`
+	Congestion float64   `json:"congestion"` // 0 (free flow) .. 1 (standstill)
+	ObservedAt time.Time `gorm:"index" json:"observed_at"`
+	ReportedBy uint      `json:"reported_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	// CellLat and CellLon are the south-west corner of the grid zone the reading lies in: its zone, or
+	// the zone of the first node of its edge. Routing reads only the readings around a route by them.
+	CellLat float64 `gorm:"index:idx_traffic_reading_cell" json:"-"`
+	CellLon float64 `gorm:"index:idx_traffic_reading_cell" json:"-"`
+}
+
+// TrafficProfile is the congestion of a zone or edge learned per hour of the week (traffic.Profile).
+type TrafficProfile struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Zone       string    `gorm:"uniqueIndex:idx_traffic_profile_key;size:32" json:"zone,omitempty"`
+	Edge       string    `gorm:"uniqueIndex:idx_traffic_profile_key;size:64" json:"edge,omitempty"`
+	Congestion []float64 `gorm:"serializer:json" json:"congestion"`
+	Readings   int       `json:"readings"`
+	FittedAt   time.Time `json:"fitted_at"`
+}
@@ -0,0 +1,220 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact monetary value in integer minor units (paise for INR).
+//
+// Arithmetic between amounts of different currencies is a programming error and panics, as does
+// overflowing int64. The zero Amount has no currency and is compatible with every currency, so it
+// can be used as an accumulator.
+type Amount struct {
+	Minor    int64  `gorm:"column:minor"`
+	Currency string `gorm:"column:currency;size:3"`
+}
+
+// DefaultCurrency is used when no currency is configured.
+const DefaultCurrency = "INR"
+
+// exponents lists the ISO 4217 currencies whose minor unit is not 1/100.
+var exponents = map[string]int{
+	"JPY": 0, "KRW": 0, "VND": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3,
+}
+
+func exponent(currency string) int {
+	if e, ok := exponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// MinorUnits returns how many minor units make one major unit of currency (100 for INR).
+func MinorUnits(currency string) int64 {
+	return scale(currency)
+}
+
+func scale(currency string) int64 {
+	s := int64(1)
+	for i := 0; i < exponent(currency); i++ {
+		s *= 10
+	}
+	return s
+}
+
+func New(minor int64, currency string) Amount {
+	return Amount{Minor: minor, Currency: currency}
+}
+
+// FromFloat converts a value in major units (rupees), rounding to the nearest minor unit with mode.
+// The decimal the float was written as is used, so FromFloat(0.285, "INR", HalfUp) is 29 paise.
+func FromFloat(v float64, currency string, mode RoundingMode) Amount {
+	r := ratOf(v)
+	r.Mul(r, new(big.Rat).SetInt64(scale(currency)))
+	return Amount{Minor: roundRat(r, mode), Currency: currency}
+}
+
+// Parse reads a decimal string in major units, e.g. "12.34".
+func Parse(s, currency string, mode RoundingMode) (Amount, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Amount{}, fmt.Errorf("money: invalid amount %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(scale(currency)))
+	return Amount{Minor: roundRat(r, mode), Currency: currency}, nil
+}
+
+func (a Amount) IsZero() bool     { return a.Minor == 0 }
+func (a Amount) IsNegative() bool { return a.Minor < 0 }
+
+// Float64 returns the value in major units. Use it for statistics and display only.
+func (a Amount) Float64() float64 {
+	return float64(a.Minor) / float64(scale(a.Currency))
+}
+
+func (a Amount) Add(b Amount) Amount {
+	cur := a.currencyWith(b)
+	sum := a.Minor + b.Minor
+	if (b.Minor > 0 && sum < a.Minor) || (b.Minor < 0 && sum > a.Minor) {
+		panic("money: overflow")
+	}
+	return Amount{Minor: sum, Currency: cur}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+func (a Amount) Neg() Amount {
+	if a.Minor == math.MinInt64 {
+		panic("money: overflow")
+	}
+	return Amount{Minor: -a.Minor, Currency: a.Currency}
+}
+
+// MulFloat multiplies by factor and rounds to a minor unit with mode.
+func (a Amount) MulFloat(factor float64, mode RoundingMode) Amount {
+	r := ratOf(factor)
+	r.Mul(r, new(big.Rat).SetInt64(a.Minor))
+	return Amount{Minor: roundRat(r, mode), Currency: a.Currency}
+}
+
+// Percent returns p percent of a, rounded with mode.
+func (a Amount) Percent(p float64, mode RoundingMode) Amount {
+	r := ratOf(p)
+	r.Mul(r, new(big.Rat).SetInt64(a.Minor))
+	r.Quo(r, big.NewRat(100, 1))
+	return Amount{Minor: roundRat(r, mode), Currency: a.Currency}
+}
+
+// Cmp returns -1, 0 or +1 as a is less than, equal to or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	a.currencyWith(b)
+	switch {
+	case a.Minor < b.Minor:
+		return -1
+	case a.Minor > b.Minor:
+		return 1
+	}
+	return 0
+}
+
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Amount) Amount {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// Decimal formats the value in major units with the currency's number of decimals, e.g. "12.30".
+func (a Amount) Decimal() string {
+	exp := exponent(a.Currency)
+	neg := a.Minor < 0
+	u := uint64(a.Minor)
+	if neg {
+		u = uint64(-(a.Minor + 1)) + 1
+	}
+	s := strconv.FormatUint(u, 10)
+	if exp > 0 {
+		for len(s) <= exp {
+			s = "0" + s
+		}
+		s = s[:len(s)-exp] + "." + s[len(s)-exp:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func (a Amount) String() string {
+	if a.Currency == "" {
+		return a.Decimal()
+	}
+	return a.Currency + " " + a.Decimal()
+}
+
+type amountJSON struct {
+	Value    string `json:"value"`
+	Minor    *int64 `json:"minor,omitempty"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes {"value":"12.34","minor":1234,"currency":"INR"}. value is a string so
+// clients never round-trip the amount through a float.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	minor := a.Minor
+	return json.Marshal(amountJSON{Value: a.Decimal(), Minor: &minor, Currency: a.Currency})
+}
+
+// UnmarshalJSON accepts the MarshalJSON form; minor wins over value when both are present.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	var in amountJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	if in.Minor != nil {
+		*a = Amount{Minor: *in.Minor, Currency: in.Currency}
+		return nil
+	}
+	v, err := Parse(in.Value, in.Currency, HalfUp)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func (a Amount) currencyWith(b Amount) string {
+	switch {
+	case a.Currency == b.Currency:
+		return a.Currency
+	case a.Currency == "" && a.Minor == 0:
+		return b.Currency
+	case b.Currency == "" && b.Minor == 0:
+		return a.Currency
+	}
+	panic(fmt.Sprintf("money: currency mismatch %s/%s", a.Currency, b.Currency))
+}
+
+// ratOf converts v exactly as written in its shortest decimal form.
+func ratOf(v float64) *big.Rat {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		panic("money: non-finite value")
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	return r
+}
@@ -0,0 +1,123 @@
+package money
+
+import "testing"
+
+func TestFromFloatRounding(t *testing.T) {
+	tests := []struct {
+		v    float64
+		mode RoundingMode
+		want int64
+	}{
+		{0.285, HalfUp, 29}, // 0.285*100 is 28.4999... as a float
+		{0.285, HalfEven, 28},
+		{0.275, HalfEven, 28},
+		{0.285, Down, 28},
+		{0.281, Up, 29},
+		{-0.285, HalfUp, -29},
+		{-0.285, HalfEven, -28},
+		{-0.289, Down, -28},
+		{-0.281, Up, -29},
+		{12.34, HalfUp, 1234},
+		{1.005, HalfUp, 101},
+		{0, HalfUp, 0},
+	}
+	for _, tt := range tests {
+		if got := FromFloat(tt.v, "INR", tt.mode); got.Minor != tt.want {
+			t.Errorf("FromFloat(%v, %v) = %d, want %d", tt.v, tt.mode, got.Minor, tt.want)
+		}
+	}
+}
+
+func TestFromFloatExponent(t *testing.T) {
+	tests := []struct {
+		v        float64
+		currency string
+		want     int64
+	}{
+		{1234.5, "JPY", 1235},
+		{1.2345, "KWD", 1235},
+		{1.2345, "INR", 123},
+	}
+	for _, tt := range tests {
+		if got := FromFloat(tt.v, tt.currency, HalfUp); got.Minor != tt.want {
+			t.Errorf("FromFloat(%v, %s) = %d, want %d", tt.v, tt.currency, got.Minor, tt.want)
+		}
+	}
+}
+
+func TestMulFloat(t *testing.T) {
+	tests := []struct {
+		minor  int64
+		factor float64
+		mode   RoundingMode
+		want   int64
+	}{
+		{1000, 1.5, HalfUp, 1500},
+		{333, 1.5, HalfUp, 500}, // 499.5
+		{333, 1.5, HalfEven, 500},
+		{335, 1.5, HalfEven, 502}, // 502.5
+		{335, 1.5, HalfUp, 503},
+		{335, 1.5, Down, 502},
+		{101, 1.01, Up, 103}, // 102.01
+		{-335, 1.5, HalfUp, -503},
+		{1000, 0, HalfUp, 0},
+	}
+	for _, tt := range tests {
+		if got := New(tt.minor, "INR").MulFloat(tt.factor, tt.mode); got.Minor != tt.want || got.Currency != "INR" {
+			t.Errorf("%d.MulFloat(%v, %v) = %v, want %d", tt.minor, tt.factor, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		minor int64
+		p     float64
+		mode  RoundingMode
+		want  int64
+	}{
+		{10000, 18, HalfUp, 1800},
+		{1250, 18, HalfUp, 225},
+		{1025, 10, HalfUp, 103}, // 102.5
+		{1025, 10, HalfEven, 102},
+		{1035, 10, HalfEven, 104},
+		{1025, 10, Down, 102},
+		{1021, 10, Up, 103},
+		{999, 2.5, HalfUp, 25}, // 24.975
+		{-1025, 10, HalfUp, -103},
+	}
+	for _, tt := range tests {
+		if got := New(tt.minor, "INR").Percent(tt.p, tt.mode); got.Minor != tt.want {
+			t.Errorf("%d.Percent(%v, %v) = %d, want %d", tt.minor, tt.p, tt.mode, got.Minor, tt.want)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for s, want := range map[string]RoundingMode{"": HalfUp, "half_up": HalfUp, "half_even": HalfEven, "down": Down, "up": Up} {
+		if got, err := ParseRoundingMode(s); err != nil || got != want {
+			t.Errorf("ParseRoundingMode(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseRoundingMode("nearest"); err == nil {
+		t.Error("ParseRoundingMode(nearest) did not fail")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		a    Amount
+		want string
+	}{
+		{New(1230, "INR"), "12.30"},
+		{New(5, "INR"), "0.05"},
+		{New(-5, "INR"), "-0.05"},
+		{New(1235, "JPY"), "1235"},
+		{New(1235, "KWD"), "1.235"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Decimal(); got != tt.want {
+			t.Errorf("%d %s Decimal() = %q, want %q", tt.a.Minor, tt.a.Currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type RoundingMode int
+
+const (
+	HalfUp   RoundingMode = iota // ties away from zero
+	HalfEven                     // ties to the even neighbour (banker's rounding)
+	Down                         // toward zero
+	Up                           // away from zero
+)
+
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch s {
+	case "", "half_up":
+		return HalfUp, nil
+	case "half_even":
+		return HalfEven, nil
+	case "down":
+		return Down, nil
+	case "up":
+		return Up, nil
+	}
+	return HalfUp, fmt.Errorf("money: unknown rounding mode %q", s)
+}
+
+// roundRat rounds r to an integer with mode.
+func roundRat(r *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		twice := new(big.Int).Lsh(rem, 1)
+		cmp := twice.Cmp(den) // <0 below half, 0 tie, >0 above half
+		roundUp := false
+		switch mode {
+		case HalfUp:
+			roundUp = cmp >= 0
+		case HalfEven:
+			roundUp = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+		case Up:
+			roundUp = true
+		case Down:
+		}
+		if roundUp {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		panic("money: overflow")
+	}
+	return q.Int64()
+}
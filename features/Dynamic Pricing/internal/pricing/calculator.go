@@ -1,8 +1,143 @@
 package pricing
 
-import "VOID/config"
+import (
+	"fmt"
+	"time"
 
-// final = base + distance*distance_weight + traffic*traffic_weight + demand*demand_weight
-func CalculatePrice(base, distanceKm, trafficScore, demandIndex float64, pcfg config.PricingCfg) float64 {
-	return base + distanceKm*pcfg.DistanceWeight + trafficScore*pcfg.TrafficWeight + demandIndex*pcfg.DemandWeight
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+// Pipeline runs a request through an ordered list of components, then the pricing rules,
+// clamps the sum to [MinFee, MaxFee] and finally applies the cart-value tier. A clamp is itemised as
+// its own line, so the lines of a breakdown always add up to its total.
+type Pipeline struct {
+	Components []Component
+	Rules      []Rule
+	CartTiers  []cartTier
+	MinFee     money.Amount
+	MaxFee     money.Amount // 0 -> no cap
+	Location   *time.Location
+	Currency   string
+	Rounding   money.RoundingMode
+}
+
+// NewPipeline builds the pipeline described by pcfg.Components.
+func NewPipeline(pcfg config.PricingCfg) (*Pipeline, error) {
+	names := pcfg.Components
+	if len(names) == 0 {
+		names = DefaultComponents
+	}
+	mode, err := money.ParseRoundingMode(pcfg.Rounding)
+	if err != nil {
+		return nil, err
+	}
+	u := units{currency: pcfg.Currency, mode: mode}
+	if u.currency == "" {
+		u.currency = money.DefaultCurrency
+	}
+	p := &Pipeline{
+		MinFee:   u.of(pcfg.MinFee),
+		MaxFee:   u.of(pcfg.MaxFee),
+		Location: time.Local,
+		Currency: u.currency,
+		Rounding: mode,
+	}
+	if pcfg.Timezone != "" {
+		loc, err := time.LoadLocation(pcfg.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		p.Location = loc
+	}
+	for _, name := range names {
+		c, err := newComponent(name, pcfg, u)
+		if err != nil {
+			return nil, err
+		}
+		p.Components = append(p.Components, c)
+	}
+	if p.CartTiers, err = compileTiers(pcfg.CartTiers, u); err != nil {
+		return nil, err
+	}
+	for _, rc := range pcfg.Rules {
+		r, err := compileRule(rc, p.Components, u)
+		if err != nil {
+			return nil, err
+		}
+		p.Rules = append(p.Rules, r)
+	}
+	return p, nil
+}
+
+func (p *Pipeline) Price(req PricingRequest) Breakdown {
+	b := Breakdown{Subtotal: money.New(0, p.Currency)}
+	for _, c := range p.Components {
+		amt := c.Fee(req)
+		if amt.Currency == "" {
+			amt.Currency = p.Currency
+		}
+		b.Lines = append(b.Lines, Line{Component: c.Name(), Amount: amt})
+		b.Subtotal = b.Subtotal.Add(amt)
+	}
+	at := req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.In(p.Location)
+	for _, r := range p.Rules {
+		if !r.Matches(req, at) {
+			continue
+		}
+		amt := r.delta(&b)
+		b.Lines = append(b.Lines, Line{Component: RuleLinePrefix + r.Name, Amount: amt})
+		b.Subtotal = b.Subtotal.Add(amt)
+		b.Rules = append(b.Rules, r.Name)
+	}
+	b.Total = b.Subtotal
+	b.Discount = money.New(0, p.Currency)
+	if b.Total.Cmp(p.MinFee) < 0 {
+		b.Lines = append(b.Lines, Line{Component: ComponentMinFee, Amount: p.MinFee.Sub(b.Total)})
+		b.Total = p.MinFee
+		b.Floored = true
+	} else if !p.MaxFee.IsZero() && b.Total.Cmp(p.MaxFee) > 0 {
+		b.Lines = append(b.Lines, Line{Component: ComponentMaxFee, Amount: p.MaxFee.Sub(b.Total)})
+		b.Total = p.MaxFee
+		b.Capped = true
+	}
+	b.ApplyDiscount(ComponentCartTier, tierReduction(p.CartTiers, req.CartValue, b.Total, p.Rounding))
+	return b
+}
+
+func validatePricing(pcfg config.PricingCfg) error {
+	if _, err := NewPipeline(pcfg); err != nil {
+		return err
+	}
+	_, err := NewPayoutModel(pcfg)
+	return err
+}
+
+// Validate builds every pipeline and payout model v can produce: the base pricing, each experiment variant and each
+// subscription plan on top of the base.
+func Validate(v *config.PricingVersion, plans []config.PlanCfg) error {
+	if err := validatePricing(v.Pricing); err != nil {
+		return err
+	}
+	for _, e := range v.Experiments {
+		for _, vr := range e.Variants {
+			if err := validatePricing(vr.Resolved); err != nil {
+				return fmt.Errorf("experiment %s/%s: %w", e.Name, vr.Name, err)
+			}
+		}
+	}
+	for i := range plans {
+		member, err := plans[i].MemberPricing(v.Pricing)
+		if err == nil {
+			err = validatePricing(member)
+		}
+		if err != nil {
+			return fmt.Errorf("plan %s: %w", plans[i].Name, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,184 @@
+package pricing
+
+import (
+	"testing"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+// basePricing prices 20 INR plus 10 INR per km, with no clamp, tiers or rules.
+func basePricing() config.PricingCfg {
+	return config.PricingCfg{
+		Currency:       "INR",
+		BasePrice:      20,
+		DistanceWeight: 10,
+		Components:     []string{ComponentBase, ComponentDistance},
+		Timezone:       "UTC",
+	}
+}
+
+func pipeline(t *testing.T, pcfg config.PricingCfg) *Pipeline {
+	t.Helper()
+	p, err := NewPipeline(pcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// checkLines fails t unless the lines of b add up to its total.
+func checkLines(t *testing.T, b Breakdown) {
+	t.Helper()
+	sum := money.New(0, b.Total.Currency)
+	for _, l := range b.Lines {
+		sum = sum.Add(l.Amount)
+	}
+	if sum != b.Total {
+		t.Errorf("lines %v add up to %s, total is %s", b.Lines, sum, b.Total)
+	}
+}
+
+func TestRuleEffects(t *testing.T) {
+	tests := []struct {
+		name      string
+		effect    config.RuleEffect
+		wantLine  int64 // minor units of the rule line
+		wantTotal int64
+	}{
+		{"add to the total", config.RuleEffect{Type: EffectAdd, Value: 5}, 500, 7500},
+		{"multiply the total", config.RuleEffect{Type: EffectMultiply, Value: 1.5}, 3500, 10500},
+		{"multiply one component", config.RuleEffect{Type: EffectMultiply, Target: ComponentDistance, Value: 2}, 5000, 12000},
+		{"override the total", config.RuleEffect{Type: EffectOverride, Value: 40}, -3000, 4000},
+		{"override one component", config.RuleEffect{Type: EffectOverride, Target: ComponentBase, Value: 0}, -2000, 5000},
+		{"cap above the total", config.RuleEffect{Type: EffectCap, Value: 100}, 0, 7000},
+		{"cap below the total", config.RuleEffect{Type: EffectCap, Value: 60}, -1000, 6000},
+	}
+	for _, tt := range tests {
+		pcfg := basePricing()
+		pcfg.Rules = []config.PricingRuleCfg{{Name: "r", Effect: tt.effect}}
+		b := pipeline(t, pcfg).Price(PricingRequest{DistanceKm: 5, At: at(0, 12, 0)})
+		if got := b.Amount(RuleLinePrefix + "r"); got.Minor != tt.wantLine {
+			t.Errorf("%s: rule line %s, want %d paise", tt.name, got, tt.wantLine)
+		}
+		if b.Total.Minor != tt.wantTotal {
+			t.Errorf("%s: total %s, want %d paise", tt.name, b.Total, tt.wantTotal)
+		}
+		checkLines(t, b)
+	}
+}
+
+func TestRulesRunInTheirTimeZone(t *testing.T) {
+	pcfg := basePricing()
+	pcfg.Timezone = "Asia/Kolkata"
+	pcfg.Rules = []config.PricingRuleCfg{{
+		Name: "night", When: config.RuleCondition{TimeFrom: "23:00", TimeTo: "05:00"}, Effect: config.RuleEffect{Type: EffectAdd, Value: 5},
+	}}
+	p := pipeline(t, pcfg)
+	// 18:00 UTC is 23:30 in Kolkata; 12:00 UTC is 17:30.
+	if b := p.Price(PricingRequest{At: at(0, 18, 0)}); len(b.Rules) != 1 {
+		t.Errorf("rule did not fire at 23:30 in Kolkata")
+	}
+	if b := p.Price(PricingRequest{At: at(0, 12, 0)}); len(b.Rules) != 0 {
+		t.Errorf("rule fired at 17:30 in Kolkata")
+	}
+}
+
+func TestMinMaxFeeLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		km        float64
+		wantLine  string
+		wantAmt   int64
+		wantTotal int64
+	}{
+		{"inside the bounds", 2, "", 0, 4000},
+		{"raised to the minimum", 0.5, ComponentMinFee, 500, 3000},
+		{"lowered to the cap", 10, ComponentMaxFee, -2000, 10000},
+	}
+	pcfg := basePricing()
+	pcfg.MinFee, pcfg.MaxFee = 30, 100
+	p := pipeline(t, pcfg)
+	for _, tt := range tests {
+		b := p.Price(PricingRequest{DistanceKm: tt.km, At: at(0, 12, 0)})
+		if b.Total.Minor != tt.wantTotal {
+			t.Errorf("%s: total %s, want %d paise", tt.name, b.Total, tt.wantTotal)
+		}
+		if b.Floored != (tt.wantLine == ComponentMinFee) || b.Capped != (tt.wantLine == ComponentMaxFee) {
+			t.Errorf("%s: floored %t, capped %t", tt.name, b.Floored, b.Capped)
+		}
+		for _, name := range []string{ComponentMinFee, ComponentMaxFee} {
+			want := int64(0)
+			if name == tt.wantLine {
+				want = tt.wantAmt
+			}
+			if got := b.Amount(name); got.Minor != want {
+				t.Errorf("%s: %s line %s, want %d paise", tt.name, name, got, want)
+			}
+		}
+		checkLines(t, b)
+	}
+}
+
+func TestCartTiersAfterTheClamp(t *testing.T) {
+	pcfg := basePricing()
+	pcfg.MinFee, pcfg.MaxFee = 30, 100
+	pcfg.CartTiers = []config.CartTierCfg{
+		{MinSubtotal: 1000, Waive: true},
+		{MinSubtotal: 200, DiscountAmount: 5},
+		{MinSubtotal: 500, DiscountPercent: 10},
+	}
+	p := pipeline(t, pcfg)
+	tests := []struct {
+		name      string
+		km        float64
+		cart      int64 // rupees
+		wantTier  int64
+		wantTotal int64
+	}{
+		{"unknown cart", 2, 0, 0, 4000},
+		{"below every tier", 2, 100, 0, 4000},
+		{"fixed amount", 2, 200, 500, 3500},
+		{"percent of the capped fee", 10, 500, 1000, 9000},
+		{"fixed amount off the minimum fee", 0.5, 300, 500, 2500},
+		{"waived", 10, 1000, 10000, 0},
+	}
+	for _, tt := range tests {
+		b := p.Price(PricingRequest{DistanceKm: tt.km, CartValue: money.New(tt.cart*100, "INR"), At: at(0, 12, 0)})
+		if got := b.Amount(ComponentCartTier); got.Minor != -tt.wantTier {
+			t.Errorf("%s: cart tier line %s, want -%d paise", tt.name, got, tt.wantTier)
+		}
+		if b.Discount.Minor != tt.wantTier || b.Total.Minor != tt.wantTotal {
+			t.Errorf("%s: discount %s, total %s; want %d and %d paise", tt.name, b.Discount, b.Total, tt.wantTier, tt.wantTotal)
+		}
+		checkLines(t, b)
+	}
+}
+
+func TestPromoAfterCartTier(t *testing.T) {
+	pcfg := basePricing()
+	pcfg.MinFee = 30
+	pcfg.CartTiers = []config.CartTierCfg{{MinSubtotal: 200, DiscountAmount: 5}}
+	tests := []struct {
+		name      string
+		promo     int64
+		wantPromo int64
+		wantTotal int64
+	}{
+		{"part of the fee", 1000, 1000, 2500},
+		{"all of the fee", 3500, 3500, 0},
+		{"more than the fee", 5000, 3500, 0},
+		{"nothing", 0, 0, 3500},
+	}
+	for _, tt := range tests {
+		b := pipeline(t, pcfg).Price(PricingRequest{DistanceKm: 2, CartValue: money.New(20000, "INR"), At: at(0, 12, 0)})
+		applied := b.ApplyDiscount(ComponentPromo, money.New(tt.promo, "INR"))
+		if applied.Minor != tt.wantPromo || b.Total.Minor != tt.wantTotal {
+			t.Errorf("%s: applied %s, total %s; want %d and %d paise", tt.name, applied, b.Total, tt.wantPromo, tt.wantTotal)
+		}
+		if b.Discount.Minor != 500+tt.wantPromo {
+			t.Errorf("%s: discount %s, want %d paise", tt.name, b.Discount, 500+tt.wantPromo)
+		}
+		checkLines(t, b)
+	}
+}
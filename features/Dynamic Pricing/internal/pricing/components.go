@@ -0,0 +1,213 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+const (
+	ComponentBase       = "base"
+	ComponentDistance   = "distance"
+	ComponentTraffic    = "traffic"
+	ComponentSurge      = "surge"
+	ComponentWeather    = "weather"
+	ComponentExpress    = "express"
+	ComponentSmallOrder = "small_order"
+	ComponentForecast   = "forecast"
+
+	// ComponentPromo is the breakdown line of a promotion discount. It is applied after the
+	// min/max clamp, so it is not a pipeline component.
+	ComponentPromo = "promo"
+
+	// ComponentMinFee and ComponentMaxFee are the breakdown lines that raise the sum of the lines to
+	// MinFee or lower it to MaxFee. They are not pipeline components either.
+	ComponentMinFee = "minimum_fee"
+	ComponentMaxFee = "fee_cap"
+)
+
+// DefaultComponents is the pipeline used when PricingCfg.Components is empty.
+var DefaultComponents = []string{
+	ComponentBase,
+	ComponentDistance,
+	ComponentTraffic,
+	ComponentSurge,
+	ComponentWeather,
+	ComponentExpress,
+	ComponentSmallOrder,
+}
+
+// Component computes a single fee line for a request.
+type Component interface {
+	Name() string
+	Fee(req PricingRequest) money.Amount
+}
+
+// units converts the major-unit values of PricingCfg into amounts in the configured currency.
+type units struct {
+	currency string
+	mode     money.RoundingMode
+}
+
+func (u units) of(v float64) money.Amount {
+	return money.FromFloat(v, u.currency, u.mode)
+}
+
+var componentFactories = map[string]func(p config.PricingCfg, u units) Component{
+	ComponentBase: func(p config.PricingCfg, u units) Component {
+		if p.WaiveBaseFee {
+			return baseFee{amount: u.of(0)}
+		}
+		return baseFee{amount: u.of(p.BasePrice)}
+	},
+	ComponentDistance: func(p config.PricingCfg, u units) Component {
+		return distanceFee{ratePerKm: u.of(p.DistanceWeight), mode: u.mode}
+	},
+	ComponentTraffic: func(p config.PricingCfg, u units) Component {
+		return trafficFee{weight: u.of(p.TrafficWeight), mode: u.mode}
+	},
+	ComponentSurge: func(p config.PricingCfg, u units) Component {
+		return surgeFee{base: u.of(p.BasePrice), maxMultiplier: p.MaxSurgeMultiplier, peak: p.PeakDemand, mode: u.mode}
+	},
+	ComponentWeather: func(p config.PricingCfg, u units) Component {
+		return weatherFee{base: u.of(p.BasePrice), multipliers: p.WeatherMultipliers, mode: u.mode}
+	},
+	ComponentExpress: func(p config.PricingCfg, u units) Component {
+		return expressFee{base: u.of(p.BasePrice), premiumPercent: p.ExpressPremiumPercent, mode: u.mode}
+	},
+	ComponentSmallOrder: func(p config.PricingCfg, u units) Component {
+		return smallOrderFee{threshold: u.of(p.SmallOrderThreshold), fee: u.of(p.SmallOrderFee)}
+	},
+	ComponentForecast: func(p config.PricingCfg, u units) Component {
+		return forecastFee{weight: u.of(p.ForecastWeight), peak: p.ForecastPeak, mode: u.mode}
+	},
+}
+
+func newComponent(name string, pcfg config.PricingCfg, u units) (Component, error) {
+	f, ok := componentFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pricing component %q", name)
+	}
+	return f(pcfg, u), nil
+}
+
+type baseFee struct{ amount money.Amount }
+
+func (c baseFee) Name() string                        { return ComponentBase }
+func (c baseFee) Fee(req PricingRequest) money.Amount { return c.amount }
+
+type distanceFee struct {
+	ratePerKm money.Amount
+	mode      money.RoundingMode
+}
+
+func (c distanceFee) Name() string { return ComponentDistance }
+func (c distanceFee) Fee(req PricingRequest) money.Amount {
+	return c.ratePerKm.MulFloat(req.DistanceKm, c.mode)
+}
+
+type trafficFee struct {
+	weight money.Amount
+	mode   money.RoundingMode
+}
+
+func (c trafficFee) Name() string { return ComponentTraffic }
+func (c trafficFee) Fee(req PricingRequest) money.Amount {
+	return c.weight.MulFloat(req.TrafficScore, c.mode)
+}
+
+type surgeFee struct {
+	base          money.Amount
+	maxMultiplier float64
+	peak          float64
+	mode          money.RoundingMode
+}
+
+func (c surgeFee) Name() string { return ComponentSurge }
+func (c surgeFee) Fee(req PricingRequest) money.Amount {
+	m := surgeMultiplier(req, c.maxMultiplier, c.peak)
+	if m <= 1 {
+		return money.Amount{}
+	}
+	return c.base.MulFloat(m-1, c.mode)
+}
+
+// SurgeMultiplier is the multiplier the surge component of p prices req at; 1 means no surge.
+func SurgeMultiplier(p config.PricingCfg, req PricingRequest) float64 {
+	return surgeMultiplier(req, p.MaxSurgeMultiplier, p.PeakDemand)
+}
+
+func surgeMultiplier(req PricingRequest, maxMultiplier, peak float64) float64 {
+	if req.SurgeCap > 0 {
+		maxMultiplier = math.Min(maxMultiplier, req.SurgeCap)
+	}
+	if maxMultiplier <= 1 {
+		return 1
+	}
+	if req.SurgeMultiplier > 0 {
+		return math.Max(math.Min(req.SurgeMultiplier, maxMultiplier), 1)
+	}
+	if peak <= 0 {
+		return 1
+	}
+	ratio := math.Min(req.DemandIndex/peak, 1)
+	return 1 + (maxMultiplier-1)*ratio
+}
+
+type weatherFee struct {
+	base        money.Amount
+	multipliers map[string]float64
+	mode        money.RoundingMode
+}
+
+func (c weatherFee) Name() string { return ComponentWeather }
+func (c weatherFee) Fee(req PricingRequest) money.Amount {
+	m, ok := c.multipliers[req.Weather]
+	if !ok {
+		return money.Amount{}
+	}
+	return c.base.MulFloat(m-1, c.mode)
+}
+
+type expressFee struct {
+	base           money.Amount
+	premiumPercent float64
+	mode           money.RoundingMode
+}
+
+func (c expressFee) Name() string { return ComponentExpress }
+func (c expressFee) Fee(req PricingRequest) money.Amount {
+	if req.Priority != "express" {
+		return money.Amount{}
+	}
+	return c.base.Percent(c.premiumPercent, c.mode)
+}
+
+type smallOrderFee struct {
+	threshold money.Amount
+	fee       money.Amount
+}
+
+func (c smallOrderFee) Name() string { return ComponentSmallOrder }
+func (c smallOrderFee) Fee(req PricingRequest) money.Amount {
+	if req.CartValue.Minor <= 0 || req.CartValue.Cmp(c.threshold) >= 0 {
+		return money.Amount{}
+	}
+	return c.fee
+}
+
+type forecastFee struct {
+	weight money.Amount
+	peak   float64
+	mode   money.RoundingMode
+}
+
+func (c forecastFee) Name() string { return ComponentForecast }
+func (c forecastFee) Fee(req PricingRequest) money.Amount {
+	if c.peak <= 0 {
+		return money.Amount{}
+	}
+	return c.weight.MulFloat(math.Min(req.ForecastDemand/c.peak, 1), c.mode)
+}
@@ -0,0 +1,42 @@
+package pricing
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"VOID/config"
+)
+
+// Assignment is the pricing config a user is priced with, and the config version and experiment
+// variant it came from.
+type Assignment struct {
+	Version    int
+	Experiment string // empty when the user is not in an experiment
+	Variant    string
+	Pricing    config.PricingCfg
+}
+
+// Assign buckets userID into a variant of the active experiment. The bucket is a hash of the
+// experiment name and user ID, so a user stays in the same variant for the life of the experiment.
+// Anonymous users (ID 0) and requests without an active experiment get the base pricing config.
+func Assign(cfg *config.Config, userID uint) Assignment {
+	live := cfg.Live()
+	exp := live.ActiveExperiment()
+	if exp == nil || userID == 0 {
+		return Assignment{Version: live.Version, Pricing: live.Pricing}
+	}
+	total := 0
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", exp.Name, userID)
+	bucket := int(h.Sum32() % uint32(total))
+	for _, v := range exp.Variants {
+		if bucket < v.Weight {
+			return Assignment{Version: live.Version, Experiment: exp.Name, Variant: v.Name, Pricing: v.Resolved}
+		}
+		bucket -= v.Weight
+	}
+	return Assignment{Version: live.Version, Pricing: live.Pricing}
+}
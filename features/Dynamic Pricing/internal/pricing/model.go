@@ -1,8 +1,77 @@
 package pricing
 
+import (
+	"time"
+
+	"VOID/internal/money"
+)
+
 type PricingRequest struct {
 	OrderID      uint
+	UserID       uint
+	Zone         string // pickup grid cell, see geo.Grid
 	DistanceKm   float64
 	TrafficScore float64
 	DemandIndex  float64
+	// ForecastDemand is the expected number of orders in Zone over the next hour.
+	ForecastDemand float64
+	// SurgeMultiplier is the zone multiplier published by the surge controller; 0 -> derive it from DemandIndex.
+	SurgeMultiplier float64
+	// SurgeCap caps the surge multiplier below the configured maximum, e.g. where conversion drops; 0 -> none.
+	SurgeCap    float64
+	Priority    string       // "normal" or "express"
+	Weather     string       // "", "rain", "storm", ...
+	CartValue   money.Amount // cart subtotal; 0 -> unknown
+	TripMinutes float64      // estimated driving time, see PayoutModel.TripMinutes
+	WaitMinutes float64      // driver wait beyond driving; 0 until the order is delivered
+	At          time.Time    // evaluation time for rule windows; zero -> now
+}
+
+// Line is one itemised fee component of a price.
+type Line struct {
+	Component string       `json:"component"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// Breakdown is the itemised result of running a PricingRequest through a Pipeline.
+type Breakdown struct {
+	Lines    []Line       `json:"lines"`
+	Rules    []string     `json:"rules,omitempty"` // names of the pricing rules that fired
+	Subtotal money.Amount `json:"subtotal"`        // sum of component and rule lines before the min/max clamp
+	Discount money.Amount `json:"discount"`        // cart tier and promotion reductions, taken off after the clamp
+	Total    money.Amount `json:"total"`
+	Floored  bool         `json:"floored"`
+	Capped   bool         `json:"capped"`
+
+	Plan          string       `json:"plan,omitempty"` // subscription plan the price was computed under
+	MemberSavings money.Amount `json:"member_savings"` // regular total minus this total, for members
+}
+
+// Member marks b as priced under plan and records the savings against the regular price of the same request.
+func (b *Breakdown) Member(plan string, regular Breakdown) {
+	b.Plan = plan
+	b.MemberSavings = money.Max(regular.Total.Sub(b.Total), money.New(0, b.Total.Currency))
+}
+
+// ApplyDiscount takes up to amount off the total and records it as its own negative line.
+// It returns the discount actually applied.
+func (b *Breakdown) ApplyDiscount(component string, amount money.Amount) money.Amount {
+	amount = money.Min(amount, b.Total)
+	if amount.Minor <= 0 {
+		return money.New(0, b.Total.Currency)
+	}
+	b.Lines = append(b.Lines, Line{Component: component, Amount: amount.Neg()})
+	b.Discount = b.Discount.Add(amount)
+	b.Total = b.Total.Sub(amount)
+	return amount
+}
+
+// Amount returns the amount of the named component, or 0 if it is not in the breakdown.
+func (b Breakdown) Amount(component string) money.Amount {
+	for _, l := range b.Lines {
+		if l.Component == component {
+			return l.Amount
+		}
+	}
+	return money.New(0, b.Total.Currency)
 }
@@ -0,0 +1,162 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+const (
+	PayoutBase       = "base"
+	PayoutDistance   = "distance"
+	PayoutTime       = "time"
+	PayoutSurgeShare = "surge_share"
+	PayoutWait       = "wait"
+	PayoutMinimum    = "minimum_payout" // tops the lines up to the minimum payout
+
+	// IncentiveLinePrefix prefixes the payout line of an incentive, e.g. "incentive:late_night".
+	IncentiveLinePrefix = "incentive:"
+)
+
+// Payout is the itemised driver pay for a request.
+type Payout struct {
+	Lines   []Line       `json:"lines"`
+	Total   money.Amount `json:"total"`
+	Floored bool         `json:"floored"` // raised to the minimum payout
+}
+
+// PayoutModel computes driver payouts from the same requests the Pipeline prices.
+type PayoutModel struct {
+	base, perKm, perMinute, waitPerMinute, minPayout money.Amount
+
+	surgeShare  float64
+	waitFree    float64
+	avgSpeedKmh float64
+	incentives  []incentive
+	location    *time.Location
+	currency    string
+	mode        money.RoundingMode
+}
+
+type incentive struct {
+	rule   Rule
+	amount money.Amount
+}
+
+// NewPayoutModel builds the payout model of pcfg.Payout, using pcfg's currency, rounding and timezone.
+func NewPayoutModel(pcfg config.PricingCfg) (*PayoutModel, error) {
+	mode, err := money.ParseRoundingMode(pcfg.Rounding)
+	if err != nil {
+		return nil, err
+	}
+	u := units{currency: pcfg.Currency, mode: mode}
+	if u.currency == "" {
+		u.currency = money.DefaultCurrency
+	}
+	pc := pcfg.Payout
+	m := &PayoutModel{
+		base:          u.of(pc.BasePerTrip),
+		perKm:         u.of(pc.PerKm),
+		perMinute:     u.of(pc.PerMinute),
+		waitPerMinute: u.of(pc.WaitPerMinute),
+		minPayout:     u.of(pc.MinPayout),
+		surgeShare:    pc.SurgeSharePercent,
+		waitFree:      pc.WaitFreeMinutes,
+		avgSpeedKmh:   pc.AvgSpeedKmh,
+		location:      time.Local,
+		currency:      u.currency,
+		mode:          mode,
+	}
+	if pcfg.Timezone != "" {
+		if m.location, err = time.LoadLocation(pcfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	for _, ic := range pc.Incentives {
+		r, err := compileRule(config.PricingRuleCfg{Name: ic.Name, When: ic.When, Effect: config.RuleEffect{Type: EffectAdd}}, nil, u)
+		if err != nil {
+			return nil, fmt.Errorf("payout incentive: %w", err)
+		}
+		m.incentives = append(m.incentives, incentive{rule: r, amount: u.of(ic.Amount)})
+	}
+	return m, nil
+}
+
+// TripMinutes estimates the driving time of req.DistanceKm, slowing down linearly to half speed at
+// traffic score 1. It is 0 when no average speed is configured.
+func (m *PayoutModel) TripMinutes(req PricingRequest) float64 {
+	if m.avgSpeedKmh <= 0 {
+		return 0
+	}
+	speed := m.avgSpeedKmh * (1 - math.Min(math.Max(req.TrafficScore, 0), 1)/2)
+	return req.DistanceKm / speed * 60
+}
+
+// Payout computes the driver pay for req. b is the customer breakdown of the same request; the driver
+// gets a share of its surge line.
+func (m *PayoutModel) Payout(req PricingRequest, b Breakdown) Payout {
+	var lines []Line
+	add := func(name string, amt money.Amount) {
+		if amt.Currency == "" {
+			amt.Currency = m.currency
+		}
+		lines = append(lines, Line{Component: name, Amount: amt})
+	}
+	add(PayoutBase, m.base)
+	add(PayoutDistance, m.perKm.MulFloat(req.DistanceKm, m.mode))
+	add(PayoutTime, m.perMinute.MulFloat(req.TripMinutes, m.mode))
+	add(PayoutSurgeShare, b.Amount(ComponentSurge).Percent(m.surgeShare, m.mode))
+	add(PayoutWait, m.WaitPay(req.WaitMinutes))
+	at := req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.In(m.location)
+	for _, ic := range m.incentives {
+		if ic.rule.Matches(req, at) {
+			add(IncentiveLinePrefix+ic.rule.Name, ic.amount)
+		}
+	}
+	return m.total(lines)
+}
+
+// WithWait replaces the wait line of p with the pay for minutes, e.g. once the actual wait is known, and
+// tops the new total up to the minimum payout afresh.
+func (m *PayoutModel) WithWait(p Payout, minutes float64) Payout {
+	lines := make([]Line, 0, len(p.Lines))
+	for _, l := range p.Lines {
+		if l.Component != PayoutWait {
+			lines = append(lines, l)
+		}
+	}
+	return m.total(append(lines, Line{Component: PayoutWait, Amount: m.WaitPay(minutes)}))
+}
+
+// total sums lines into a Payout, replacing any minimum_payout line with the top-up the lines now need,
+// so that the lines always add up to the total.
+func (m *PayoutModel) total(lines []Line) Payout {
+	p := Payout{Total: money.New(0, m.currency)}
+	for _, l := range lines {
+		if l.Component != PayoutMinimum {
+			p.Lines = append(p.Lines, l)
+			p.Total = p.Total.Add(l.Amount)
+		}
+	}
+	if p.Total.Cmp(m.minPayout) < 0 {
+		p.Lines = append(p.Lines, Line{Component: PayoutMinimum, Amount: m.minPayout.Sub(p.Total)})
+		p.Total = m.minPayout
+		p.Floored = true
+	}
+	return p
+}
+
+// WaitPay is the compensation for waiting minutes beyond the free allowance.
+func (m *PayoutModel) WaitPay(minutes float64) money.Amount {
+	if minutes <= m.waitFree {
+		return money.New(0, m.currency)
+	}
+	return m.waitPerMinute.MulFloat(minutes-m.waitFree, m.mode)
+}
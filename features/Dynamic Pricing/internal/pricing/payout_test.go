@@ -0,0 +1,128 @@
+package pricing
+
+import (
+	"testing"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+// payoutPricing pays 10 INR a trip, 1.20 per km, 0.25 per minute and 70% of the surge line, with five
+// free minutes of wait at 1 INR a minute after, at least 15 INR and 10 more late at night.
+func payoutPricing() config.PricingCfg {
+	pcfg := basePricing()
+	pcfg.Payout = config.PayoutCfg{
+		BasePerTrip: 10, PerKm: 1.2, PerMinute: 0.25, AvgSpeedKmh: 30, SurgeSharePercent: 70,
+		WaitFreeMinutes: 5, WaitPerMinute: 1, MinPayout: 15,
+		Incentives: []config.IncentiveCfg{{Name: "late_night", When: config.RuleCondition{TimeFrom: "23:00", TimeTo: "05:00"}, Amount: 10}},
+	}
+	return pcfg
+}
+
+func payoutModel(t *testing.T) *PayoutModel {
+	t.Helper()
+	m, err := NewPayoutModel(payoutPricing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// checkPayout fails t unless the lines of p add up to its total.
+func checkPayout(t *testing.T, p Payout) {
+	t.Helper()
+	checkLines(t, Breakdown{Lines: p.Lines, Total: p.Total})
+}
+
+func payoutLine(p Payout, name string) money.Amount {
+	return Breakdown{Lines: p.Lines, Total: money.New(0, p.Total.Currency)}.Amount(name)
+}
+
+func TestPayout(t *testing.T) {
+	m := payoutModel(t)
+	surge := Breakdown{Lines: []Line{{Component: ComponentSurge, Amount: money.New(1000, "INR")}}}
+	tests := []struct {
+		name        string
+		req         PricingRequest
+		b           Breakdown
+		wantTotal   int64
+		wantMinimum int64 // minor units of the minimum_payout line
+		wantBonus   bool
+	}{
+		// 10 + 10*1.20 + 20*0.25 = 27
+		{"long trip", PricingRequest{DistanceKm: 10, TripMinutes: 20, At: at(0, 12, 0)}, Breakdown{}, 2700, 0, false},
+		// 27 + 70% of 10 surge = 34
+		{"surge share", PricingRequest{DistanceKm: 10, TripMinutes: 20, At: at(0, 12, 0)}, surge, 3400, 0, false},
+		// 27 + 8 minutes of paid wait
+		{"paid wait", PricingRequest{DistanceKm: 10, TripMinutes: 20, WaitMinutes: 13, At: at(0, 12, 0)}, Breakdown{}, 3500, 0, false},
+		// 10 + 1.20 + 0.50 = 11.70, topped up to 15
+		{"short trip", PricingRequest{DistanceKm: 1, TripMinutes: 2, At: at(0, 12, 0)}, Breakdown{}, 1500, 330, false},
+		// free wait adds nothing
+		{"short trip with free wait", PricingRequest{DistanceKm: 1, TripMinutes: 2, WaitMinutes: 5, At: at(0, 12, 0)}, Breakdown{}, 1500, 330, false},
+		// 11.70 + 10 late night
+		{"late night incentive", PricingRequest{DistanceKm: 1, TripMinutes: 2, At: at(0, 23, 30)}, Breakdown{}, 2170, 0, true},
+	}
+	for _, tt := range tests {
+		p := m.Payout(tt.req, tt.b)
+		if p.Total.Minor != tt.wantTotal {
+			t.Errorf("%s: total %s, want %d paise", tt.name, p.Total, tt.wantTotal)
+		}
+		if got := payoutLine(p, PayoutMinimum); got.Minor != tt.wantMinimum || p.Floored != (tt.wantMinimum > 0) {
+			t.Errorf("%s: minimum payout line %s, floored %t; want %d paise", tt.name, got, p.Floored, tt.wantMinimum)
+		}
+		if got := !payoutLine(p, IncentiveLinePrefix+"late_night").IsZero(); got != tt.wantBonus {
+			t.Errorf("%s: late night incentive paid %t, want %t", tt.name, got, tt.wantBonus)
+		}
+		checkPayout(t, p)
+	}
+}
+
+func TestWithWaitRetopsTheMinimum(t *testing.T) {
+	m := payoutModel(t)
+	short := m.Payout(PricingRequest{DistanceKm: 1, TripMinutes: 2, At: at(0, 12, 0)}, Breakdown{})
+	tests := []struct {
+		minutes     float64
+		wantTotal   int64
+		wantMinimum int64
+	}{
+		{0, 1500, 330},
+		{7, 1500, 130},   // 11.70 + 2 wait
+		{10, 1670, 0},    // 11.70 + 5 wait
+		{5.5, 1500, 280}, // 11.70 + 0.50 wait
+	}
+	for _, tt := range tests {
+		p := m.WithWait(short, tt.minutes)
+		if p.Total.Minor != tt.wantTotal || payoutLine(p, PayoutMinimum).Minor != tt.wantMinimum {
+			t.Errorf("wait %v: total %s, minimum payout line %s; want %d and %d paise",
+				tt.minutes, p.Total, payoutLine(p, PayoutMinimum), tt.wantTotal, tt.wantMinimum)
+		}
+		n := 0
+		for _, l := range p.Lines {
+			if l.Component == PayoutWait || l.Component == PayoutMinimum {
+				n++
+			}
+		}
+		want := 1
+		if tt.wantMinimum > 0 {
+			want++
+		}
+		if n != want {
+			t.Errorf("wait %v: %d wait and minimum lines in %v, want %d", tt.minutes, n, p.Lines, want)
+		}
+		checkPayout(t, p)
+	}
+}
+
+func TestTripMinutes(t *testing.T) {
+	m := payoutModel(t)
+	for _, tt := range []struct{ km, traffic, want float64 }{
+		{10, 0, 20},
+		{10, 1, 40},
+		{10, 0.5, 10 / 22.5 * 60},
+		{10, 2, 40}, // traffic is clamped to 1
+	} {
+		if got := m.TripMinutes(PricingRequest{DistanceKm: tt.km, TrafficScore: tt.traffic}); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("TripMinutes(%v km, traffic %v) = %v, want %v", tt.km, tt.traffic, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+const (
+	EffectAdd      = "add"
+	EffectMultiply = "multiply"
+	EffectOverride = "override"
+	EffectCap      = "cap"
+
+	TargetTotal = "total"
+
+	// RuleLinePrefix prefixes the breakdown line a fired rule adds, e.g. "rule:night_surcharge".
+	RuleLinePrefix = "rule:"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Rule is a compiled config.PricingRuleCfg.
+type Rule struct {
+	Name string
+	cfg  config.RuleCondition
+	eff  config.RuleEffect
+
+	value   money.Amount // eff.Value for add/override/cap
+	minCart money.Amount
+	maxCart money.Amount
+	mode    money.RoundingMode
+
+	hasWindow bool
+	fromMin   int
+	toMin     int
+	days      map[time.Weekday]bool
+}
+
+func compileRule(rc config.PricingRuleCfg, components []Component, u units) (Rule, error) {
+	r := Rule{
+		Name:    rc.Name,
+		cfg:     rc.When,
+		eff:     rc.Effect,
+		value:   u.of(rc.Effect.Value),
+		minCart: u.of(rc.When.MinCartValue),
+		maxCart: u.of(rc.When.MaxCartValue),
+		mode:    u.mode,
+	}
+	if r.Name == "" {
+		return r, fmt.Errorf("pricing rule without name")
+	}
+	switch r.eff.Type {
+	case EffectAdd, EffectMultiply, EffectOverride, EffectCap:
+	default:
+		return r, fmt.Errorf("rule %s: unknown effect %q", r.Name, r.eff.Type)
+	}
+	if r.eff.Target == "" {
+		r.eff.Target = TargetTotal
+	}
+	if r.eff.Target != TargetTotal && !hasComponent(components, r.eff.Target) {
+		return r, fmt.Errorf("rule %s: target %q is not in the pipeline", r.Name, r.eff.Target)
+	}
+	if rc.When.TimeFrom != "" || rc.When.TimeTo != "" {
+		var err error
+		if r.fromMin, err = parseClock(rc.When.TimeFrom); err != nil {
+			return r, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		if r.toMin, err = parseClock(rc.When.TimeTo); err != nil {
+			return r, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		// Equal bounds would make an empty window; a rule for the whole day sets neither.
+		if r.fromMin == r.toMin {
+			return r, fmt.Errorf("rule %s: time_from and time_to are both %s; omit them for the whole day", r.Name, rc.When.TimeFrom)
+		}
+		r.hasWindow = true
+	}
+	if len(rc.When.Weekdays) > 0 {
+		r.days = map[time.Weekday]bool{}
+		for _, d := range rc.When.Weekdays {
+			wd, ok := weekdays[strings.ToLower(d)]
+			if !ok {
+				return r, fmt.Errorf("rule %s: unknown weekday %q", r.Name, d)
+			}
+			r.days[wd] = true
+		}
+	}
+	return r, nil
+}
+
+// Matches reports whether every condition set on the rule holds for req at local time t.
+func (r Rule) Matches(req PricingRequest, t time.Time) bool {
+	c := r.cfg
+	if r.hasWindow {
+		m := t.Hour()*60 + t.Minute()
+		if r.fromMin <= r.toMin {
+			if m < r.fromMin || m >= r.toMin {
+				return false
+			}
+		} else if m < r.fromMin && m >= r.toMin { // window wraps past midnight
+			return false
+		}
+	}
+	if r.days != nil && !r.days[t.Weekday()] {
+		return false
+	}
+	if len(c.Priorities) > 0 && !contains(c.Priorities, req.Priority) {
+		return false
+	}
+	if len(c.Zones) > 0 && !contains(c.Zones, req.Zone) {
+		return false
+	}
+	if c.MinDistanceKm > 0 && req.DistanceKm < c.MinDistanceKm {
+		return false
+	}
+	if c.MaxDistanceKm > 0 && req.DistanceKm >= c.MaxDistanceKm {
+		return false
+	}
+	if (c.MinCartValue > 0 || c.MaxCartValue > 0) && req.CartValue.Minor <= 0 {
+		return false
+	}
+	if c.MinCartValue > 0 && req.CartValue.Cmp(r.minCart) < 0 {
+		return false
+	}
+	if c.MaxCartValue > 0 && req.CartValue.Cmp(r.maxCart) >= 0 {
+		return false
+	}
+	return true
+}
+
+// delta is the amount the rule adds to the breakdown, relative to the current target amount.
+func (r Rule) delta(b *Breakdown) money.Amount {
+	cur := b.Subtotal
+	if r.eff.Target != TargetTotal {
+		cur = b.Amount(r.eff.Target)
+	}
+	switch r.eff.Type {
+	case EffectAdd:
+		return r.value
+	case EffectMultiply:
+		return cur.MulFloat(r.eff.Value-1, r.mode)
+	case EffectOverride:
+		return r.value.Sub(cur)
+	case EffectCap:
+		if cur.Cmp(r.value) > 0 {
+			return r.value.Sub(cur)
+		}
+	}
+	return money.New(0, cur.Currency)
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func hasComponent(components []Component, name string) bool {
+	for _, c := range components {
+		if c.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+var inr = units{currency: "INR", mode: money.HalfUp}
+
+// at is a time on Monday 19 October 2026, or days later, at hh:mm.
+func at(days, hh, mm int) time.Time {
+	return time.Date(2026, 10, 19+days, hh, mm, 0, 0, time.UTC)
+}
+
+func TestRuleMatches(t *testing.T) {
+	trip := PricingRequest{Priority: "normal", Zone: "12.9600:77.5800", DistanceKm: 5, CartValue: money.New(30000, "INR")}
+	with := func(edit func(*PricingRequest)) PricingRequest {
+		req := trip
+		edit(&req)
+		return req
+	}
+	day := config.RuleCondition{TimeFrom: "09:00", TimeTo: "17:00"}
+	night := config.RuleCondition{TimeFrom: "23:00", TimeTo: "05:00"}
+	weekend := config.RuleCondition{Weekdays: []string{"sat", "SUN"}}
+	tests := []struct {
+		name string
+		when config.RuleCondition
+		req  PricingRequest
+		t    time.Time
+		want bool
+	}{
+		{"no conditions", config.RuleCondition{}, trip, at(0, 12, 0), true},
+		{"before the window", day, trip, at(0, 8, 59), false},
+		{"window start is inside", day, trip, at(0, 9, 0), true},
+		{"last minute of the window", day, trip, at(0, 16, 59), true},
+		{"window end is outside", day, trip, at(0, 17, 0), false},
+		{"wrapping window before midnight", night, trip, at(0, 23, 0), true},
+		{"wrapping window after midnight", night, trip, at(1, 2, 0), true},
+		{"last minute of a wrapping window", night, trip, at(1, 4, 59), true},
+		{"wrapping window end is outside", night, trip, at(1, 5, 0), false},
+		{"between a wrapping window's ends", night, trip, at(0, 12, 0), false},
+		{"just before a wrapping window", night, trip, at(0, 22, 59), false},
+		{"listed weekday", weekend, trip, at(5, 12, 0), true},
+		{"weekday in capitals", weekend, trip, at(6, 12, 0), true},
+		{"unlisted weekday", weekend, trip, at(0, 12, 0), false},
+		{"window and weekday", config.RuleCondition{TimeFrom: "23:00", TimeTo: "05:00", Weekdays: []string{"sat"}}, trip, at(5, 23, 30), true},
+		{"window on another weekday", config.RuleCondition{TimeFrom: "23:00", TimeTo: "05:00", Weekdays: []string{"sat"}}, trip, at(4, 23, 30), false},
+		{"priority", config.RuleCondition{Priorities: []string{"express"}}, with(func(r *PricingRequest) { r.Priority = "express" }), at(0, 12, 0), true},
+		{"other priority", config.RuleCondition{Priorities: []string{"express"}}, trip, at(0, 12, 0), false},
+		{"zone", config.RuleCondition{Zones: []string{"12.9600:77.5800"}}, trip, at(0, 12, 0), true},
+		{"other zone", config.RuleCondition{Zones: []string{"12.9700:77.5800"}}, trip, at(0, 12, 0), false},
+		{"minimum distance is inclusive", config.RuleCondition{MinDistanceKm: 5}, trip, at(0, 12, 0), true},
+		{"below the minimum distance", config.RuleCondition{MinDistanceKm: 5.1}, trip, at(0, 12, 0), false},
+		{"maximum distance is exclusive", config.RuleCondition{MaxDistanceKm: 5}, trip, at(0, 12, 0), false},
+		{"minimum cart is inclusive", config.RuleCondition{MinCartValue: 300}, trip, at(0, 12, 0), true},
+		{"maximum cart is exclusive", config.RuleCondition{MaxCartValue: 300}, trip, at(0, 12, 0), false},
+		{"unknown cart never matches a cart bound", config.RuleCondition{MaxCartValue: 300},
+			with(func(r *PricingRequest) { r.CartValue = money.Amount{} }), at(0, 12, 0), false},
+	}
+	for _, tt := range tests {
+		r, err := compileRule(config.PricingRuleCfg{Name: "r", When: tt.when, Effect: config.RuleEffect{Type: EffectAdd}}, nil, inr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := r.Matches(tt.req, tt.t); got != tt.want {
+			t.Errorf("%s: Matches at %s = %t, want %t", tt.name, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestCompileRuleRejects(t *testing.T) {
+	components := []Component{baseFee{}, distanceFee{}}
+	add := config.RuleEffect{Type: EffectAdd, Value: 5}
+	tests := map[string]config.PricingRuleCfg{
+		"no name":                {When: config.RuleCondition{}, Effect: add},
+		"unknown effect":         {Name: "r", Effect: config.RuleEffect{Type: "discount"}},
+		"target not in pipeline": {Name: "r", Effect: config.RuleEffect{Type: EffectAdd, Target: ComponentSurge}},
+		"equal window bounds":    {Name: "r", When: config.RuleCondition{TimeFrom: "10:00", TimeTo: "10:00"}, Effect: add},
+		"window without an end":  {Name: "r", When: config.RuleCondition{TimeFrom: "10:00"}, Effect: add},
+		"hour out of range":      {Name: "r", When: config.RuleCondition{TimeFrom: "25:00", TimeTo: "05:00"}, Effect: add},
+		"unknown weekday":        {Name: "r", When: config.RuleCondition{Weekdays: []string{"funday"}}, Effect: add},
+	}
+	for name, rc := range tests {
+		if _, err := compileRule(rc, components, inr); err == nil {
+			t.Errorf("%s: compileRule accepted %+v", name, rc)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+
+	"VOID/config"
+	"VOID/internal/money"
+)
+
+// ComponentCartTier is the breakdown line of a cart-value fee reduction.
+const ComponentCartTier = "cart_tier"
+
+type cartTier struct {
+	min     money.Amount
+	amount  money.Amount
+	percent float64
+	waive   bool
+}
+
+func compileTiers(tiers []config.CartTierCfg, u units) ([]cartTier, error) {
+	out := make([]cartTier, 0, len(tiers))
+	for _, t := range tiers {
+		set := 0
+		if t.DiscountAmount > 0 {
+			set++
+		}
+		if t.DiscountPercent > 0 {
+			set++
+		}
+		if t.Waive {
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("cart tier %.2f: set exactly one of discount_amount, discount_percent, waive", t.MinSubtotal)
+		}
+		out = append(out, cartTier{min: u.of(t.MinSubtotal), amount: u.of(t.DiscountAmount), percent: t.DiscountPercent, waive: t.Waive})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].min.Cmp(out[j].min) < 0 })
+	return out, nil
+}
+
+// reduction is what the tier matching cart takes off total, or zero when no tier matches.
+func tierReduction(tiers []cartTier, cart, total money.Amount, mode money.RoundingMode) money.Amount {
+	if cart.Minor <= 0 {
+		return money.New(0, total.Currency)
+	}
+	var match *cartTier
+	for i := range tiers {
+		if cart.Cmp(tiers[i].min) >= 0 {
+			match = &tiers[i]
+		}
+	}
+	switch {
+	case match == nil:
+		return money.New(0, total.Currency)
+	case match.waive:
+		return total
+	case match.percent > 0:
+		return total.Percent(match.percent, mode)
+	}
+	return match.amount
+}
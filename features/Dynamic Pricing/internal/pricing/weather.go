@@ -0,0 +1,239 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/geo"
+)
+
+// WeatherProvider returns the weather condition ("", "rain", "storm", ...) at a location and time.
+// The condition is looked up in PricingCfg.WeatherMultipliers by the weather component.
+type WeatherProvider interface {
+	Conditions(lat, lon float64, at time.Time) (string, error)
+}
+
+// NoWeather reports clear weather everywhere.
+type NoWeather struct{}
+
+func (NoWeather) Conditions(lat, lon float64, at time.Time) (string, error) { return "", nil }
+
+// WeatherObservation is one entry of a weather feed: a condition over a circle, optionally bounded in time.
+type WeatherObservation struct {
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	RadiusKm  float64   `json:"radius_km"`
+	From      time.Time `json:"from"` // zero -> open
+	To        time.Time `json:"to"`   // zero -> open
+	Condition string    `json:"condition"`
+}
+
+func (o WeatherObservation) covers(lat, lon float64, at time.Time) (float64, bool) {
+	if (!o.From.IsZero() && at.Before(o.From)) || (!o.To.IsZero() && !at.Before(o.To)) {
+		return 0, false
+	}
+	d := geo.DistanceMeters(o.Lat, o.Lon, lat, lon) / 1000
+	return d, d <= o.RadiusKm
+}
+
+// FileWeather serves conditions from a local JSON or CSV feed (chosen by extension) and reloads it when
+// the file's modification time changes, checking at most once per CheckEvery.
+// Where observations overlap, the one whose centre is nearest wins.
+//
+// JSON is an array of WeatherObservation. CSV has the header lat,lon,radius_km,from,to,condition with
+// RFC 3339 times; from and to may be empty.
+type FileWeather struct {
+	Path       string
+	CheckEvery time.Duration
+
+	mu      sync.Mutex
+	obs     []WeatherObservation
+	modTime time.Time
+	checked time.Time
+}
+
+func NewFileWeather(path string, checkEvery time.Duration) (*FileWeather, error) {
+	w := &FileWeather{Path: path, CheckEvery: checkEvery}
+	if err := w.reload(time.Now()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWeather) Conditions(lat, lon float64, at time.Time) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if now.Sub(w.checked) >= w.CheckEvery {
+		// Keep serving the last good feed if the file is mid-write or briefly missing.
+		if err := w.reload(now); err != nil {
+			return w.lookup(lat, lon, at), err
+		}
+	}
+	return w.lookup(lat, lon, at), nil
+}
+
+func (w *FileWeather) lookup(lat, lon float64, at time.Time) string {
+	cond, best := "", math.Inf(1)
+	for _, o := range w.obs {
+		if d, ok := o.covers(lat, lon, at); ok && d < best {
+			cond, best = o.Condition, d
+		}
+	}
+	return cond
+}
+
+// reload must be called with w.mu held (or before w is shared).
+func (w *FileWeather) reload(now time.Time) error {
+	w.checked = now
+	st, err := os.Stat(w.Path)
+	if err != nil {
+		return err
+	}
+	if st.ModTime().Equal(w.modTime) && w.obs != nil {
+		return nil
+	}
+	obs, err := readWeatherFeed(w.Path)
+	if err != nil {
+		return fmt.Errorf("weather feed %s: %w", w.Path, err)
+	}
+	w.obs, w.modTime = obs, st.ModTime()
+	return nil
+}
+
+func readWeatherFeed(path string) ([]WeatherObservation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	obs := []WeatherObservation{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(f).Decode(&obs); err != nil {
+			return nil, err
+		}
+		return obs, nil
+	}
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range records {
+		if i == 0 {
+			continue // header
+		}
+		if len(r) != 6 {
+			return nil, fmt.Errorf("line %d: want 6 fields, got %d", i+1, len(r))
+		}
+		var o WeatherObservation
+		var perr error
+		parse := func(s string) float64 {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil && perr == nil {
+				perr = err
+			}
+			return v
+		}
+		parseTime := func(s string) time.Time {
+			if s = strings.TrimSpace(s); s == "" {
+				return time.Time{}
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil && perr == nil {
+				perr = err
+			}
+			return t
+		}
+		o.Lat, o.Lon, o.RadiusKm = parse(r[0]), parse(r[1]), parse(r[2])
+		o.From, o.To = parseTime(r[3]), parseTime(r[4])
+		o.Condition = strings.TrimSpace(r[5])
+		if perr != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, perr)
+		}
+		obs = append(obs, o)
+	}
+	return obs, nil
+}
+
+// CacheWeather serves current conditions from the cache, keyed by grid cell, so a feeder process (or
+// another instance) can publish them. On a miss it asks Source, if set, and caches the answer for TTL. A
+// cached condition is only as current as TTL, so a time further than TTL from now is asked of Source
+// directly.
+type CacheWeather struct {
+	Cache  *cache.Cache
+	Grid   geo.Grid
+	TTL    time.Duration
+	Source WeatherProvider
+}
+
+// WeatherKey is the cache key of the condition of a grid zone.
+func WeatherKey(zone string) string {
+	return "weather:" + zone
+}
+
+func (w *CacheWeather) Conditions(lat, lon float64, at time.Time) (string, error) {
+	if d := time.Since(at); !at.IsZero() && (d > w.TTL || d < -w.TTL) {
+		if w.Source == nil {
+			return "", nil
+		}
+		return w.Source.Conditions(lat, lon, at)
+	}
+	key := WeatherKey(w.Grid.ZoneID(lat, lon))
+	if v, err := w.Cache.Get(key); err == nil && v != "" {
+		if v == clearWeather {
+			return "", nil
+		}
+		return v, nil
+	}
+	if w.Source == nil {
+		return "", nil
+	}
+	cond, err := w.Source.Conditions(lat, lon, at)
+	if err != nil {
+		return "", err
+	}
+	stored := cond
+	if stored == "" {
+		stored = clearWeather
+	}
+	return cond, w.Cache.Set(key, stored, w.TTL)
+}
+
+// clearWeather is cached for "no condition", since an empty value reads as a miss.
+const clearWeather = "clear"
+
+// NewWeatherProvider builds the provider selected by cfg.Weather.Provider: "file", "cache" (read-through
+// to the file feed when one is configured) or "" for none.
+func NewWeatherProvider(cfg *config.Config, c *cache.Cache) (WeatherProvider, error) {
+	wc := cfg.Weather
+	var file WeatherProvider
+	if wc.File != "" {
+		fw, err := NewFileWeather(wc.File, wc.CheckEvery)
+		if err != nil {
+			return nil, err
+		}
+		file = fw
+	}
+	switch wc.Provider {
+	case "":
+		return NoWeather{}, nil
+	case "file":
+		if file == nil {
+			return nil, fmt.Errorf("weather provider file needs weather.file")
+		}
+		return file, nil
+	case "cache":
+		return &CacheWeather{Cache: c, Grid: geo.Grid{CellSize: cfg.Zones.CellSizeDeg}, TTL: wc.CacheTTL, Source: file}, nil
+	}
+	return nil, fmt.Errorf("unknown weather provider %q", wc.Provider)
+}
@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"VOID/internal/cache"
+	"VOID/internal/geo"
+)
+
+// rainAt reports rain from a time on, and counts the calls.
+type rainAt struct {
+	from  time.Time
+	calls int
+}
+
+func (s *rainAt) Conditions(_, _ float64, at time.Time) (string, error) {
+	s.calls++
+	if at.Before(s.from) {
+		return "", nil
+	}
+	return "rain", nil
+}
+
+func TestCacheWeatherOnlyCachesTheCurrentCondition(t *testing.T) {
+	now := time.Now()
+	src := &rainAt{from: now.Add(2 * time.Hour)}
+	w := &CacheWeather{Cache: &cache.Cache{}, Grid: geo.Grid{CellSize: 0.005}, TTL: 10 * time.Minute, Source: src}
+	tests := []struct {
+		name      string
+		at        time.Time
+		want      string
+		wantCalls int
+	}{
+		{"now, from the source", now, "", 1},
+		{"now again, from the cache", now.Add(time.Minute), "", 1},
+		{"in three hours, from the source", now.Add(3 * time.Hour), "rain", 2},
+		{"in three hours again, still from the source", now.Add(3 * time.Hour), "rain", 3},
+		{"now after asking about later, from the cache", now, "", 3},
+	}
+	for _, tt := range tests {
+		got, err := w.Conditions(12.97, 77.59, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || src.calls != tt.wantCalls {
+			t.Errorf("%s: %q after %d source calls, want %q after %d", tt.name, got, src.calls, tt.want, tt.wantCalls)
+		}
+	}
+
+	// A condition a feeder published for now does not answer for a time it was not observed at.
+	if err := w.Cache.Set(WeatherKey(w.Grid.ZoneID(12.97, 77.59)), "storm", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := w.Conditions(12.97, 77.59, now); got != "storm" {
+		t.Errorf("published condition read as %q, want storm", got)
+	}
+	if got, _ := w.Conditions(12.97, 77.59, now.Add(-3*time.Hour)); got != "" {
+		t.Errorf("three hours ago read as %q, want clear", got)
+	}
+}
@@ -0,0 +1,21 @@
+package routing
+
+import "VOID/internal/geo"
+
+// AStar is Dijkstra guided towards the target by the great-circle distance to it at the graph's top
+// speed. The heuristic never overestimates as long as the weights are at least the free-flow travel
+// times, which holds for FreeFlow and any congestion that only slows edges down.
+type AStar struct{ G *Graph }
+
+func (AStar) Name() string { return "astar" }
+
+func (a AStar) ShortestPath(from, to int32, w WeightFunc) (Path, bool) {
+	g := a.G
+	if g.TopSpeed <= 0 {
+		return search(g, from, to, w, nil)
+	}
+	target := g.Nodes[to]
+	return search(g, from, to, w, func(n int32) float64 {
+		return geo.DistanceMeters(g.Nodes[n].Lat, g.Nodes[n].Lon, target.Lat, target.Lon) / g.TopSpeed
+	})
+}
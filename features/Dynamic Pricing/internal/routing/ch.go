@@ -0,0 +1,394 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"time"
+)
+
+// Arc is an edge of a contraction hierarchy: a road edge (Via -1) or a shortcut for the two arcs through Via.
+type Arc struct {
+	To      int32
+	Via     int32
+	Seconds float64
+}
+
+// CH is a contraction hierarchy over the free-flow travel times of a graph. Nodes are ranked by the order
+// they were contracted in; the arcs leaving node i towards higher-ranked nodes are Up[UpFirst[i]:UpFirst[i+1]],
+// and the arcs entering it from higher-ranked nodes Down[DownFirst[i]:DownFirst[i+1]], with To the other end.
+type CH struct {
+	Graph     *Graph
+	Rank      []int32
+	UpFirst   []int32
+	Up        []Arc
+	DownFirst []int32
+	Down      []Arc
+	// Source is the SourceKey of the extract and speeds the hierarchy was built from; empty if unknown.
+	Source string
+
+	states sync.Pool // *chQuery
+}
+
+// Shortcuts returns the number of arcs that are not road edges.
+func (h *CH) Shortcuts() int {
+	n := 0
+	for _, arcs := range [][]Arc{h.Up, h.Down} {
+		for _, a := range arcs {
+			if a.Via >= 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// witnessSettleLimit bounds the local searches that decide whether contracting a node needs a shortcut.
+// Giving up early only adds shortcuts that are not strictly needed.
+const witnessSettleLimit = 500
+
+// Contract builds the contraction hierarchy of g. Nodes are contracted in order of edge difference (the
+// shortcuts contracting them adds less the arcs it removes) plus the number of neighbours already contracted,
+// which keeps the hierarchy even across the graph.
+func Contract(g *Graph) *CH {
+	n := len(g.Nodes)
+	c := &contractor{
+		out:        make([][]Arc, n),
+		in:         make([][]Arc, n),
+		contracted: make([]bool, n),
+		deleted:    make([]int, n),
+		dist:       make([]float64, n),
+	}
+	for i := range c.dist {
+		c.dist[i] = math.Inf(1)
+	}
+	for u := int32(0); int(u) < n; u++ {
+		for _, e := range g.Out(u) {
+			c.out[u] = append(c.out[u], Arc{To: e.To, Via: -1, Seconds: e.Seconds})
+			c.in[e.To] = append(c.in[e.To], Arc{To: u, Via: -1, Seconds: e.Seconds})
+		}
+	}
+
+	pq := &nodeQueue{}
+	for v := int32(0); int(v) < n; v++ {
+		heap.Push(pq, queued{node: v, key: c.priority(v, len(c.shortcuts(v)))})
+	}
+	rank := make([]int32, n)
+	next := int32(0)
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(queued)
+		v := it.node
+		shortcuts := c.shortcuts(v)
+		// Priorities go stale as neighbours are contracted; re-queue v if it is no longer the cheapest.
+		if p := c.priority(v, len(shortcuts)); pq.Len() > 0 && p > (*pq)[0].key {
+			heap.Push(pq, queued{node: v, key: p})
+			continue
+		}
+		for _, s := range shortcuts {
+			c.addArc(s.from, Arc{To: s.to, Via: v, Seconds: s.seconds})
+		}
+		c.contracted[v] = true
+		rank[v] = next
+		next++
+		for _, a := range c.out[v] {
+			c.deleted[a.To]++
+		}
+		for _, a := range c.in[v] {
+			c.deleted[a.To]++
+		}
+	}
+
+	h := &CH{Graph: g, Rank: rank, UpFirst: make([]int32, n+1), DownFirst: make([]int32, n+1)}
+	up := make([][]Arc, n)
+	down := make([][]Arc, n)
+	for u := range c.out {
+		for _, a := range c.out[u] {
+			if rank[a.To] > rank[u] {
+				up[u] = append(up[u], a)
+			} else {
+				down[a.To] = append(down[a.To], Arc{To: int32(u), Via: a.Via, Seconds: a.Seconds})
+			}
+		}
+	}
+	h.Up, h.UpFirst = flatten(up)
+	h.Down, h.DownFirst = flatten(down)
+	return h
+}
+
+func flatten(lists [][]Arc) ([]Arc, []int32) {
+	first := make([]int32, len(lists)+1)
+	var arcs []Arc
+	for i, l := range lists {
+		arcs = append(arcs, l...)
+		first[i+1] = int32(len(arcs))
+	}
+	return arcs, first
+}
+
+type contractor struct {
+	out, in    [][]Arc // in holds the arcs entering a node with To the node they come from
+	contracted []bool
+	deleted    []int // contracted neighbours
+	dist       []float64
+	touched    []int32
+}
+
+type shortcut struct {
+	from, to int32
+	seconds  float64
+}
+
+func (c *contractor) priority(v int32, shortcuts int) float64 {
+	degree := 0
+	for _, a := range c.out[v] {
+		if !c.contracted[a.To] {
+			degree++
+		}
+	}
+	for _, a := range c.in[v] {
+		if !c.contracted[a.To] {
+			degree++
+		}
+	}
+	return float64(shortcuts-degree+c.deleted[v]) + float64(v)/float64(len(c.out)+1) // ties by node for a stable order
+}
+
+// shortcuts returns the shortcuts contracting v needs: one for each pair of uncontracted neighbours u, w whose
+// fastest connection goes through v.
+func (c *contractor) shortcuts(v int32) []shortcut {
+	var out []shortcut
+	maxOut := 0.0
+	for _, b := range c.out[v] {
+		if !c.contracted[b.To] {
+			maxOut = math.Max(maxOut, b.Seconds)
+		}
+	}
+	for _, a := range c.in[v] {
+		u := a.To
+		if c.contracted[u] {
+			continue
+		}
+		c.witness(u, v, a.Seconds+maxOut)
+		for _, b := range c.out[v] {
+			w := b.To
+			if w == u || c.contracted[w] {
+				continue
+			}
+			if via := a.Seconds + b.Seconds; c.dist[w] > via {
+				out = append(out, shortcut{from: u, to: w, seconds: via})
+			}
+		}
+		c.reset()
+	}
+	return out
+}
+
+// witness runs Dijkstra from src over the uncontracted nodes other than skip, up to limit seconds, into c.dist.
+func (c *contractor) witness(src, skip int32, limit float64) {
+	c.dist[src] = 0
+	c.touched = append(c.touched, src)
+	pq := &nodeQueue{{node: src}}
+	for settled := 0; pq.Len() > 0 && settled < witnessSettleLimit; settled++ {
+		it := heap.Pop(pq).(queued)
+		u := it.node
+		if it.cost > c.dist[u] {
+			continue
+		}
+		if it.cost > limit {
+			return
+		}
+		for _, a := range c.out[u] {
+			if a.To == skip || c.contracted[a.To] {
+				continue
+			}
+			if d := it.cost + a.Seconds; d < c.dist[a.To] {
+				if math.IsInf(c.dist[a.To], 1) {
+					c.touched = append(c.touched, a.To)
+				}
+				c.dist[a.To] = d
+				heap.Push(pq, queued{node: a.To, cost: d, key: d})
+			}
+		}
+	}
+}
+
+func (c *contractor) reset() {
+	for _, n := range c.touched {
+		c.dist[n] = math.Inf(1)
+	}
+	c.touched = c.touched[:0]
+}
+
+// addArc adds the arc from u, or lowers the time of the existing arc between the same nodes.
+func (c *contractor) addArc(u int32, a Arc) {
+	for i := range c.out[u] {
+		if c.out[u][i].To != a.To {
+			continue
+		}
+		if a.Seconds < c.out[u][i].Seconds {
+			c.out[u][i] = a
+			for j := range c.in[a.To] {
+				if c.in[a.To][j].To == u {
+					c.in[a.To][j] = Arc{To: u, Via: a.Via, Seconds: a.Seconds}
+				}
+			}
+		}
+		return
+	}
+	c.out[u] = append(c.out[u], a)
+	c.in[a.To] = append(c.in[a.To], Arc{To: u, Via: a.Via, Seconds: a.Seconds})
+}
+
+func (*CH) Name() string { return "ch" }
+
+// ShortestPath finds the fastest path at free flow with a bidirectional search that only climbs the
+// hierarchy from either end. The hierarchy is fixed at contraction, so w does not steer the search; it
+// times the path found, as Cost.
+func (h *CH) ShortestPath(from, to int32, w WeightFunc) (Path, bool) {
+	start := time.Now()
+	q, _ := h.states.Get().(*chQuery)
+	if q == nil {
+		q = newCHQuery(len(h.Rank))
+	}
+	defer h.states.Put(q)
+	defer q.reset()
+
+	q.fwd.init(from)
+	q.bwd.init(to)
+	best, meet := math.Inf(1), int32(-1)
+	expanded := 0
+	for {
+		fwdOpen := q.fwd.queue.Len() > 0 && q.fwd.queue[0].key < best
+		bwdOpen := q.bwd.queue.Len() > 0 && q.bwd.queue[0].key < best
+		if !fwdOpen && !bwdOpen {
+			break
+		}
+		side, other, arcs, first := &q.fwd, &q.bwd, h.Up, h.UpFirst
+		if !fwdOpen || (bwdOpen && q.bwd.queue[0].key < q.fwd.queue[0].key) {
+			side, other, arcs, first = &q.bwd, &q.fwd, h.Down, h.DownFirst
+		}
+		it := heap.Pop(&side.queue).(queued)
+		u := it.node
+		if it.cost > side.dist[u] {
+			continue
+		}
+		expanded++
+		if d := it.cost + other.dist[u]; d < best {
+			best, meet = d, u
+		}
+		for i := first[u]; i < first[u+1]; i++ {
+			side.relax(u, i, it.cost+arcs[i].Seconds, arcs[i].To)
+		}
+	}
+	if meet < 0 {
+		return Path{Expanded: expanded, Elapsed: time.Since(start)}, false
+	}
+
+	// Forward arcs lead up from from to meet, backward arcs down from meet to to.
+	var up []int32
+	for n := meet; n != from; n = q.fwd.prev[n] {
+		up = append(up, q.fwd.via[n])
+	}
+	nodes := []int32{from}
+	for i := len(up) - 1; i >= 0; i-- {
+		nodes = h.unpack(nodes, h.Up[up[i]].Via, h.Up[up[i]].To)
+	}
+	for n := meet; n != to; n = q.bwd.prev[n] {
+		a := h.Down[q.bwd.via[n]]
+		nodes = h.unpack(nodes, a.Via, q.bwd.prev[n])
+	}
+	p := Path{Nodes: nodes, Expanded: expanded}
+	ok := h.Graph.walk(&p, w)
+	p.Elapsed = time.Since(start)
+	return p, ok
+}
+
+// unpack appends the road nodes of the arc from the last node of nodes to to, after the first.
+func (h *CH) unpack(nodes []int32, via, to int32) []int32 {
+	if via < 0 {
+		return append(nodes, to)
+	}
+	from := nodes[len(nodes)-1]
+	// The arc from -> via enters via from above; via -> to leaves it upwards.
+	var first, second int32 = -1, -1
+	for i := h.DownFirst[via]; i < h.DownFirst[via+1]; i++ {
+		if h.Down[i].To == from {
+			first = h.Down[i].Via
+		}
+	}
+	for i := h.UpFirst[via]; i < h.UpFirst[via+1]; i++ {
+		if h.Up[i].To == to {
+			second = h.Up[i].Via
+		}
+	}
+	nodes = h.unpack(nodes, first, via)
+	return h.unpack(nodes, second, to)
+}
+
+// walk fills in the cost under w, length and free-flow time of p.Nodes; false if two consecutive nodes
+// are not joined by an edge.
+func (g *Graph) walk(p *Path, w WeightFunc) bool {
+	for i := 1; i < len(p.Nodes); i++ {
+		e := g.edge(p.Nodes[i-1], p.Nodes[i])
+		if e == nil {
+			return false
+		}
+		p.Cost += w(p.Nodes[i-1], e, p.Cost)
+		p.Meters += e.Meters
+		p.Seconds += e.Seconds
+	}
+	return true
+}
+
+// chQuery is the reusable state of one query; only the nodes it touched are reset afterwards.
+type chQuery struct {
+	fwd, bwd chSide
+}
+
+type chSide struct {
+	dist    []float64
+	prev    []int32
+	via     []int32 // index of the arc that reached the node
+	touched []int32
+	queue   nodeQueue
+}
+
+func newCHQuery(n int) *chQuery {
+	q := &chQuery{}
+	for _, s := range []*chSide{&q.fwd, &q.bwd} {
+		s.dist = make([]float64, n)
+		s.prev = make([]int32, n)
+		s.via = make([]int32, n)
+		for i := range s.dist {
+			s.dist[i] = math.Inf(1)
+		}
+	}
+	return q
+}
+
+func (s *chSide) init(n int32) {
+	s.dist[n] = 0
+	s.touched = append(s.touched, n)
+	s.queue = append(s.queue, queued{node: n})
+}
+
+func (s *chSide) relax(u, arc int32, d float64, v int32) {
+	if d >= s.dist[v] {
+		return
+	}
+	if math.IsInf(s.dist[v], 1) {
+		s.touched = append(s.touched, v)
+	}
+	s.dist[v], s.prev[v], s.via[v] = d, u, arc
+	heap.Push(&s.queue, queued{node: v, cost: d, key: d})
+}
+
+func (q *chQuery) reset() {
+	for _, s := range []*chSide{&q.fwd, &q.bwd} {
+		for _, n := range s.touched {
+			s.dist[n] = math.Inf(1)
+		}
+		s.touched = s.touched[:0]
+		s.queue = s.queue[:0]
+	}
+}
@@ -0,0 +1,246 @@
+package routing
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sampleOSM = "../../config/roads.osm"
+
+func sampleCH(t *testing.T) *CH {
+	t.Helper()
+	g, err := Load(sampleOSM, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Contract(g)
+}
+
+// randomGraph is a size x size street grid with random speeds, one-way streets and missing blocks, so
+// that some pairs are only reachable the long way round and some not at all.
+func randomGraph(rnd *rand.Rand, size int) *Graph {
+	x := newExtract(Options{})
+	id := func(r, c int) int64 { return int64(r*size + c + 1) }
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			x.node(id(r, c), 12.9+float64(r)*0.002, 77.5+float64(c)*0.002)
+		}
+	}
+	highways := []string{"residential", "tertiary", "secondary", "primary"}
+	oneways := []string{"", "", "", "yes", "-1"}
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			for _, next := range [][2]int{{r, c + 1}, {r + 1, c}} {
+				if next[0] == size || next[1] == size || rnd.Intn(10) == 0 {
+					continue
+				}
+				x.way([]int64{id(r, c), id(next[0], next[1])}, map[string]string{
+					"highway": highways[rnd.Intn(len(highways))],
+					"oneway":  oneways[rnd.Intn(len(oneways))],
+				})
+			}
+		}
+	}
+	return x.build()
+}
+
+// sameAsDijkstra checks that h answers from -> to like Dijkstra on its graph, with a path of road edges.
+func sameAsDijkstra(t *testing.T, h *CH, from, to int32) {
+	t.Helper()
+	g := h.Graph
+	want, wantOK := Dijkstra{G: g}.ShortestPath(from, to, FreeFlow)
+	got, gotOK := h.ShortestPath(from, to, FreeFlow)
+	if gotOK != wantOK {
+		t.Fatalf("%d -> %d: hierarchy found %t, dijkstra found %t", from, to, gotOK, wantOK)
+	}
+	if !gotOK {
+		return
+	}
+	if math.Abs(got.Cost-want.Cost) > 1e-6*math.Max(1, want.Cost) {
+		t.Fatalf("%d -> %d: hierarchy %.3fs, dijkstra %.3fs", from, to, got.Cost, want.Cost)
+	}
+	if got.Nodes[0] != from || got.Nodes[len(got.Nodes)-1] != to {
+		t.Fatalf("%d -> %d: path runs %d -> %d", from, to, got.Nodes[0], got.Nodes[len(got.Nodes)-1])
+	}
+	seconds := 0.0
+	for i := 1; i < len(got.Nodes); i++ {
+		e := g.edge(got.Nodes[i-1], got.Nodes[i])
+		if e == nil {
+			t.Fatalf("%d -> %d: no road from %d to %d", from, to, got.Nodes[i-1], got.Nodes[i])
+		}
+		seconds += e.Seconds
+	}
+	if math.Abs(seconds-got.Cost) > 1e-6*math.Max(1, got.Cost) {
+		t.Fatalf("%d -> %d: unpacked path takes %.3fs, query reported %.3fs", from, to, seconds, got.Cost)
+	}
+}
+
+func TestCHMatchesDijkstraOnSample(t *testing.T) {
+	h := sampleCH(t)
+	n := int32(len(h.Graph.Nodes))
+	if n == 0 {
+		t.Fatal("empty sample graph")
+	}
+	for from := int32(0); from < n; from++ {
+		for to := int32(0); to < n; to++ {
+			sameAsDijkstra(t, h, from, to)
+		}
+	}
+}
+
+func TestCHMatchesDijkstraOnRandomGraphs(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		h := Contract(randomGraph(rnd, 25))
+		n := len(h.Graph.Nodes)
+		for i := 0; i < 300; i++ {
+			sameAsDijkstra(t, h, int32(rnd.Intn(n)), int32(rnd.Intn(n)))
+		}
+	}
+}
+
+func TestCHFileRoundTrip(t *testing.T) {
+	h := sampleCH(t)
+	h.Source = "0123456789abcdef"
+	path := filepath.Join(t.TempDir(), "roads.ch")
+	if err := WriteCH(path, h); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadCH(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Source != h.Source || got.Graph.TopSpeed != h.Graph.TopSpeed || got.Graph.Version() != h.Graph.Version() {
+		t.Errorf("read source %q, top speed %v, version %s; wrote %q, %v, %s", got.Source, got.Graph.TopSpeed,
+			got.Graph.Version(), h.Source, h.Graph.TopSpeed, h.Graph.Version())
+	}
+	for name, pair := range map[string][2]interface{}{
+		"nodes": {got.Graph.Nodes, h.Graph.Nodes}, "first": {got.Graph.First, h.Graph.First}, "edges": {got.Graph.Edges, h.Graph.Edges},
+		"rank": {got.Rank, h.Rank}, "up first": {got.UpFirst, h.UpFirst}, "up": {got.Up, h.Up},
+		"down first": {got.DownFirst, h.DownFirst}, "down": {got.Down, h.Down},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("%s differ after the round trip", name)
+		}
+	}
+	if err := Verify(got, 500, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadCHRejectsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.ch")
+	if err := WriteCH(good, sampleCH(t)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files whose arrays check() must refuse, written from a damaged hierarchy.
+	damaged := map[string]func(h *CH){
+		"edge to unknown node": func(h *CH) { h.Graph.Edges[0].To = int32(len(h.Graph.Nodes)) },
+		"short rank":           func(h *CH) { h.Rank = h.Rank[1:] },
+		"offsets out of order": func(h *CH) { h.UpFirst[1], h.UpFirst[2] = h.UpFirst[2], h.UpFirst[1]+1 },
+		"offsets past the end": func(h *CH) { h.DownFirst[len(h.DownFirst)-1]++ },
+		"arc down the ranks": func(h *CH) {
+			for u := range h.Rank {
+				if h.UpFirst[u] < h.UpFirst[u+1] {
+					h.Rank[u] = int32(len(h.Rank))
+					return
+				}
+			}
+		},
+		"arc to unknown node": func(h *CH) { h.Down[0].To = -1 },
+	}
+	for name, damage := range damaged {
+		h := sampleCH(t)
+		damage(h)
+		path := filepath.Join(dir, "damaged.ch")
+		if err := WriteCH(path, h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadCH(path); err == nil {
+			t.Errorf("%s: ReadCH accepted the file", name)
+		}
+	}
+
+	// Files damaged on disk.
+	wrongVersion := append([]byte(nil), raw...)
+	wrongVersion[len(chMagic)-1]++
+	for name, b := range map[string][]byte{
+		"truncated":     raw[:len(raw)/2],
+		"empty":         nil,
+		"wrong version": wrongVersion,
+	} {
+		path := filepath.Join(dir, "bad.ch")
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadCH(path); err == nil {
+			t.Errorf("%s: ReadCH accepted the file", name)
+		}
+	}
+}
+
+func TestSourceKey(t *testing.T) {
+	base, err := SourceKey(sampleOSM, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := SourceKey(sampleOSM, Options{Speeds: map[string]float64{"residential": DefaultSpeeds["residential"]}}); again != base {
+		t.Errorf("restating a default speed changed the key: %s, %s", again, base)
+	}
+	if slower, _ := SourceKey(sampleOSM, Options{Speeds: map[string]float64{"residential": 5}}); slower == base {
+		t.Error("changing a speed kept the key")
+	}
+	raw, err := os.ReadFile(sampleOSM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := filepath.Join(t.TempDir(), "roads.osm")
+	if err := os.WriteFile(edited, append(raw, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := SourceKey(edited, Options{}); other == base {
+		t.Error("editing the extract kept the key")
+	}
+}
+
+func TestCHAStarMatchesDijkstraUnderCongestion(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		h := Contract(randomGraph(rnd, 20))
+		g := h.Graph
+		// Congestion per edge that also rises with the time the edge is entered, no faster than time
+		// passes, so that leaving later never arrives earlier.
+		slow := make(map[*Edge]float64)
+		for i := range g.Edges {
+			slow[&g.Edges[i]] = 2 * rnd.Float64()
+		}
+		weights := map[string]WeightFunc{
+			"free flow": FreeFlow,
+			"congested": func(_ int32, e *Edge, _ float64) float64 { return e.Seconds * (1 + slow[e]) },
+			"rush hour": func(_ int32, e *Edge, elapsed float64) float64 {
+				return e.Seconds * (1 + slow[e]*math.Min(1, elapsed/600))
+			},
+		}
+		n := len(g.Nodes)
+		for name, w := range weights {
+			for i := 0; i < 200; i++ {
+				from, to := int32(rnd.Intn(n)), int32(rnd.Intn(n))
+				want, wantOK := Dijkstra{G: g}.ShortestPath(from, to, w)
+				got, gotOK := CHAStar{H: h}.ShortestPath(from, to, w)
+				if gotOK != wantOK || math.Abs(got.Cost-want.Cost) > 1e-6*math.Max(1, want.Cost) {
+					t.Fatalf("%s, %d -> %d: ch-astar %.3fs (%t), dijkstra %.3fs (%t)", name, from, to, got.Cost, gotOK, want.Cost, wantOK)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package routing
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// chMagic starts a hierarchy file; the last byte is the format version.
+var chMagic = [8]byte{'V', 'O', 'I', 'D', 'C', 'H', 0, 2}
+
+// SourceKey fingerprints what a hierarchy is built from: the bytes of the OSM extract at osmPath and the
+// speed of every highway type under opts. A hierarchy whose Source differs is out of date.
+func SourceKey(osmPath string, opts Options) (string, error) {
+	f, err := os.Open(osmPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	highways := make([]string, 0, len(DefaultSpeeds)+len(opts.Speeds))
+	for hw := range DefaultSpeeds {
+		highways = append(highways, hw)
+	}
+	for hw := range opts.Speeds {
+		if _, ok := DefaultSpeeds[hw]; !ok {
+			highways = append(highways, hw)
+		}
+	}
+	sort.Strings(highways)
+	for _, hw := range highways {
+		fmt.Fprintf(h, "\n%s=%g", hw, opts.speed(hw))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
+
+// WriteCH writes h and its graph to path: the magic, the graph's top speed, the source key as a length
+// and its bytes, then each array as a little-endian length and its elements.
+func WriteCH(path string, h *CH) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	g := h.Graph
+	for _, v := range []interface{}{chMagic, g.TopSpeed} {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	err = writeSlice(w, []byte(h.Source))
+	if err == nil {
+		err = writeSlice(w, g.Nodes)
+	}
+	if err == nil {
+		err = writeSlice(w, g.First)
+	}
+	if err == nil {
+		err = writeSlice(w, g.Edges)
+	}
+	if err == nil {
+		err = writeSlice(w, h.Rank)
+	}
+	if err == nil {
+		err = writeSlice(w, h.UpFirst)
+	}
+	if err == nil {
+		err = writeSlice(w, h.Up)
+	}
+	if err == nil {
+		err = writeSlice(w, h.DownFirst)
+	}
+	if err == nil {
+		err = writeSlice(w, h.Down)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeSlice[T Node | Edge | Arc | int32 | byte](w io.Writer, s []T) error {
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(s))); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, s)
+}
+
+// ReadCH reads a hierarchy written by WriteCH.
+func ReadCH(path string) (*CH, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var magic [8]byte
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if magic != chMagic {
+		return nil, fmt.Errorf("%s: not a contraction hierarchy file of this version", path)
+	}
+	g := &Graph{}
+	h := &CH{Graph: g}
+	if err := binary.Read(r, binary.LittleEndian, &g.TopSpeed); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var source []byte
+	err = readSlice(r, &source)
+	h.Source = string(source)
+	if err == nil {
+		err = readSlice(r, &g.Nodes)
+	}
+	if err == nil {
+		err = readSlice(r, &g.First)
+	}
+	if err == nil {
+		err = readSlice(r, &g.Edges)
+	}
+	if err == nil {
+		err = readSlice(r, &h.Rank)
+	}
+	if err == nil {
+		err = readSlice(r, &h.UpFirst)
+	}
+	if err == nil {
+		err = readSlice(r, &h.Up)
+	}
+	if err == nil {
+		err = readSlice(r, &h.DownFirst)
+	}
+	if err == nil {
+		err = readSlice(r, &h.Down)
+	}
+	if err == nil {
+		err = h.check()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g.indexNodes()
+	return h, nil
+}
+
+// maxCHSlice bounds the arrays of a file so a corrupt length fails instead of allocating without limit.
+const maxCHSlice = math.MaxInt32
+
+func readSlice[T Node | Edge | Arc | int32 | byte](r io.Reader, dst *[]T) error {
+	var n uint64
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return err
+	}
+	if n > maxCHSlice {
+		return errors.New("array too long")
+	}
+	s := make([]T, n)
+	if err := binary.Read(r, binary.LittleEndian, s); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	*dst = s
+	return nil
+}
+
+// check verifies that the arrays of a file fit together, so queries cannot index out of range, and that
+// arcs climb the ranks and shortcuts pass below both ends, so unpacking ends.
+func (h *CH) check() error {
+	g := h.Graph
+	n := len(g.Nodes)
+	if len(g.First) != n+1 || len(h.Rank) != n || len(h.UpFirst) != n+1 || len(h.DownFirst) != n+1 {
+		return errors.New("array lengths do not match the node count")
+	}
+	offsets := func(first []int32, count int) bool {
+		for i := 1; i < len(first); i++ {
+			if first[i] < first[i-1] {
+				return false
+			}
+		}
+		return first[0] == 0 && int(first[n]) == count
+	}
+	if !offsets(g.First, len(g.Edges)) || !offsets(h.UpFirst, len(h.Up)) || !offsets(h.DownFirst, len(h.Down)) {
+		return errors.New("invalid adjacency offsets")
+	}
+	for _, e := range g.Edges {
+		if e.To < 0 || int(e.To) >= n {
+			return errors.New("edge to unknown node")
+		}
+	}
+	for u := 0; u < n; u++ {
+		for _, a := range append(h.Up[h.UpFirst[u]:h.UpFirst[u+1]:h.UpFirst[u+1]], h.Down[h.DownFirst[u]:h.DownFirst[u+1]]...) {
+			if a.To < 0 || int(a.To) >= n || a.Via < -1 || int(a.Via) >= n {
+				return errors.New("arc to unknown node")
+			}
+			if h.Rank[a.To] <= h.Rank[u] || (a.Via >= 0 && h.Rank[a.Via] >= h.Rank[u]) {
+				return errors.New("arc out of rank order")
+			}
+		}
+	}
+	return nil
+}
+
+// Verify runs queries between random node pairs on h and on Dijkstra over its graph and reports the
+// first whose free-flow travel time or reachability differs. A hierarchy path that does not unpack to
+// road edges is reported as not found.
+func Verify(h *CH, queries int, seed int64) error {
+	g := h.Graph
+	if len(g.Nodes) == 0 {
+		return nil
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	ref := Dijkstra{G: g}
+	for i := 0; i < queries; i++ {
+		from, to := int32(rnd.Intn(len(g.Nodes))), int32(rnd.Intn(len(g.Nodes)))
+		want, wantOK := ref.ShortestPath(from, to, FreeFlow)
+		got, gotOK := h.ShortestPath(from, to, FreeFlow)
+		if gotOK && (got.Nodes[0] != from || got.Nodes[len(got.Nodes)-1] != to) {
+			return fmt.Errorf("%d -> %d: path does not join the query nodes", g.Nodes[from].OSMID, g.Nodes[to].OSMID)
+		}
+		if gotOK != wantOK || math.Abs(got.Cost-want.Cost) > 1e-6*math.Max(1, want.Cost) {
+			return fmt.Errorf("%d -> %d: hierarchy %.3fs (found %t), dijkstra %.3fs (found %t)",
+				g.Nodes[from].OSMID, g.Nodes[to].OSMID, got.Cost, gotOK, want.Cost, wantOK)
+		}
+	}
+	return nil
+}
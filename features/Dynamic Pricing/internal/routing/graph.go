@@ -0,0 +1,206 @@
+// Package routing builds a directed road graph from OpenStreetMap extracts and finds routes on it.
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"sort"
+
+	"VOID/internal/geo"
+)
+
+// Node is a graph vertex: an OSM node on at least one routable way.
+type Node struct {
+	OSMID int64
+	Lat   float64
+	Lon   float64
+}
+
+// Edge is a directed road segment between two consecutive nodes of a way.
+type Edge struct {
+	To      int32
+	Meters  float64
+	Seconds float64 // free-flow travel time
+}
+
+// Graph is a directed road graph in compressed adjacency form: the edges leaving node i are
+// Edges[First[i]:First[i+1]].
+type Graph struct {
+	Nodes []Node
+	First []int32
+	Edges []Edge
+	// TopSpeed is the highest free-flow speed of any edge, in m/s.
+	TopSpeed float64
+
+	index   map[int64]int32 // OSM node ID -> node
+	cells   map[cellKey][]int32
+	version string
+}
+
+// snapCellDeg is the size of the buckets Nearest searches, about 1 km.
+const snapCellDeg = 0.01
+
+type cellKey struct{ row, col int32 }
+
+func cellOf(lat, lon float64) cellKey {
+	return cellKey{int32(math.Floor(lat / snapCellDeg)), int32(math.Floor(lon / snapCellDeg))}
+}
+
+// Node returns the node of an OSM node ID.
+func (g *Graph) Node(osmID int64) (int32, bool) {
+	n, ok := g.index[osmID]
+	return n, ok
+}
+
+// Version identifies the nodes and edges of the graph, whether built from an extract or read from a
+// hierarchy file: routes computed on graphs of the same version are comparable.
+func (g *Graph) Version() string {
+	return g.version
+}
+
+// fingerprint hashes the nodes and edges; encoding to a hash does not fail.
+func (g *Graph) fingerprint() string {
+	h := sha256.New()
+	_ = binary.Write(h, binary.LittleEndian, g.Nodes)
+	_ = binary.Write(h, binary.LittleEndian, g.First)
+	_ = binary.Write(h, binary.LittleEndian, g.Edges)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// Out returns the edges leaving n.
+func (g *Graph) Out(n int32) []Edge {
+	return g.Edges[g.First[n]:g.First[n+1]]
+}
+
+// Nearest returns the node closest to the point and its distance in meters, or -1 for an empty graph.
+func (g *Graph) Nearest(lat, lon float64) (int32, float64) {
+	best, bestM := int32(-1), math.Inf(1)
+	if len(g.Nodes) == 0 {
+		return best, bestM
+	}
+	c := cellOf(lat, lon)
+	// A node in ring r is at least (r-1) cells away, so stop once that exceeds the best distance found.
+	cellM := snapCellDeg * 111000 * math.Cos(lat*math.Pi/180)
+	for r := int32(0); r <= maxSnapRings; r++ {
+		if best >= 0 && float64(r-1)*cellM > bestM {
+			break
+		}
+		for dr := -r; dr <= r; dr++ {
+			for dc := -r; dc <= r; dc++ {
+				if max32(abs32(dr), abs32(dc)) != r {
+					continue
+				}
+				for _, n := range g.cells[cellKey{c.row + dr, c.col + dc}] {
+					if m := geo.DistanceMeters(lat, lon, g.Nodes[n].Lat, g.Nodes[n].Lon); m < bestM {
+						best, bestM = n, m
+					}
+				}
+			}
+		}
+	}
+	return best, bestM
+}
+
+// maxSnapRings bounds the search of Nearest to about 50 km around the point.
+const maxSnapRings = 50
+
+// Builder collects nodes and edges for a Graph.
+type Builder struct {
+	nodes []Node
+	index map[int64]int32
+	edges []pending
+}
+
+type pending struct {
+	from int32
+	edge Edge
+}
+
+func NewBuilder() *Builder {
+	return &Builder{index: map[int64]int32{}}
+}
+
+// Node adds the OSM node (once) and returns its graph node.
+func (b *Builder) Node(osmID int64, lat, lon float64) int32 {
+	if n, ok := b.index[osmID]; ok {
+		return n
+	}
+	n := int32(len(b.nodes))
+	b.nodes = append(b.nodes, Node{OSMID: osmID, Lat: lat, Lon: lon})
+	b.index[osmID] = n
+	return n
+}
+
+// Edge adds a directed edge driven at speedKmh.
+func (b *Builder) Edge(from, to int32, speedKmh float64) {
+	f, t := b.nodes[from], b.nodes[to]
+	m := geo.DistanceMeters(f.Lat, f.Lon, t.Lat, t.Lon)
+	b.edges = append(b.edges, pending{from: from, edge: Edge{To: to, Meters: m, Seconds: m / (speedKmh / 3.6)}})
+}
+
+// Build returns the graph. When ways yield the same edge more than once, the fastest is kept.
+func (b *Builder) Build() *Graph {
+	sort.SliceStable(b.edges, func(i, j int) bool {
+		if b.edges[i].from != b.edges[j].from {
+			return b.edges[i].from < b.edges[j].from
+		}
+		if b.edges[i].edge.To != b.edges[j].edge.To {
+			return b.edges[i].edge.To < b.edges[j].edge.To
+		}
+		return b.edges[i].edge.Seconds < b.edges[j].edge.Seconds
+	})
+	g := &Graph{Nodes: b.nodes, First: make([]int32, len(b.nodes)+1)}
+	for i, p := range b.edges {
+		if p.edge.To == p.from || (i > 0 && b.edges[i-1].from == p.from && b.edges[i-1].edge.To == p.edge.To) {
+			continue
+		}
+		g.Edges = append(g.Edges, p.edge)
+		if p.edge.Seconds > 0 {
+			g.TopSpeed = math.Max(g.TopSpeed, p.edge.Meters/p.edge.Seconds)
+		}
+		g.First[p.from+1]++
+	}
+	for i := 1; i < len(g.First); i++ {
+		g.First[i] += g.First[i-1]
+	}
+	g.indexNodes()
+	return g
+}
+
+// indexNodes builds the OSM ID index, the snapping grid and the version of the graph.
+func (g *Graph) indexNodes() {
+	g.version = g.fingerprint()
+	g.index = make(map[int64]int32, len(g.Nodes))
+	g.cells = map[cellKey][]int32{}
+	for i, n := range g.Nodes {
+		g.index[n.OSMID] = int32(i)
+		k := cellOf(n.Lat, n.Lon)
+		g.cells[k] = append(g.cells[k], int32(i))
+	}
+}
+
+// edge returns the edge from u to v, or nil.
+func (g *Graph) edge(u, v int32) *Edge {
+	for i := g.First[u]; i < g.First[u+1]; i++ {
+		if g.Edges[i].To == v {
+			return &g.Edges[i]
+		}
+	}
+	return nil
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,215 @@
+package routing
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultSpeeds are the speeds in km/h of the highway types routed on, for ways without a usable maxspeed.
+// Ways with any other highway value (footway, cycleway, path, construction, ...) are not routable.
+var DefaultSpeeds = map[string]float64{
+	"motorway":       90,
+	"motorway_link":  45,
+	"trunk":          70,
+	"trunk_link":     40,
+	"primary":        50,
+	"primary_link":   30,
+	"secondary":      40,
+	"secondary_link": 30,
+	"tertiary":       30,
+	"tertiary_link":  25,
+	"unclassified":   25,
+	"residential":    20,
+	"living_street":  10,
+	"service":        15,
+	"road":           20,
+}
+
+// Options tunes graph building. Speeds overrides DefaultSpeeds per highway type; a speed of 0 excludes
+// the type.
+type Options struct {
+	Speeds map[string]float64
+}
+
+func (o Options) speed(highway string) float64 {
+	if v, ok := o.Speeds[highway]; ok {
+		return v
+	}
+	return DefaultSpeeds[highway]
+}
+
+// Load builds the graph of the OSM extract at path, read as PBF when the name ends in .pbf and as XML
+// otherwise.
+func Load(path string, opts Options) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	x := newExtract(opts)
+	if strings.HasSuffix(path, ".pbf") {
+		err = readPBF(f, x)
+	} else {
+		err = readXML(f, x)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return x.build(), nil
+}
+
+// extract accumulates the node coordinates and routable ways of a file; ways may come before the
+// nodes they reference.
+type extract struct {
+	opts   Options
+	coords map[int64][2]float64
+	ways   []way
+}
+
+type way struct {
+	refs     []int64
+	speed    float64
+	forward  bool
+	backward bool
+}
+
+func newExtract(opts Options) *extract {
+	return &extract{opts: opts, coords: map[int64][2]float64{}}
+}
+
+func (x *extract) node(id int64, lat, lon float64) {
+	x.coords[id] = [2]float64{lat, lon}
+}
+
+// way keeps a way if its tags make it routable for motor vehicles.
+func (x *extract) way(refs []int64, tags map[string]string) {
+	hw := tags["highway"]
+	speed := x.opts.speed(hw)
+	if speed <= 0 || len(refs) < 2 || tags["area"] == "yes" {
+		return
+	}
+	switch tags["access"] {
+	case "no", "private":
+		return
+	}
+	if tags["motor_vehicle"] == "no" || tags["motorcar"] == "no" {
+		return
+	}
+	if v := maxSpeed(tags["maxspeed"]); v > 0 {
+		speed = v
+	}
+	w := way{refs: refs, speed: speed, forward: true, backward: true}
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		w.backward = false
+	case "-1", "reverse":
+		w.forward = false
+	case "no", "false", "0":
+	default:
+		if hw == "motorway" || hw == "motorway_link" || tags["junction"] == "roundabout" || tags["junction"] == "circular" {
+			w.backward = false
+		}
+	}
+	x.ways = append(x.ways, w)
+}
+
+// build adds each way's nodes and segments; segments touching a node missing from the extract are dropped.
+func (x *extract) build() *Graph {
+	b := NewBuilder()
+	for _, w := range x.ways {
+		for i := 1; i < len(w.refs); i++ {
+			p, okP := x.coords[w.refs[i-1]]
+			q, okQ := x.coords[w.refs[i]]
+			if !okP || !okQ {
+				continue
+			}
+			from := b.Node(w.refs[i-1], p[0], p[1])
+			to := b.Node(w.refs[i], q[0], q[1])
+			if w.forward {
+				b.Edge(from, to, w.speed)
+			}
+			if w.backward {
+				b.Edge(to, from, w.speed)
+			}
+		}
+	}
+	return b.Build()
+}
+
+// maxSpeed parses a maxspeed tag in km/h ("50", "50 km/h") or mph ("30 mph"); 0 for anything else, such as
+// "none", "walk" or zone codes like "DE:urban".
+func maxSpeed(v string) float64 {
+	v = strings.TrimSpace(v)
+	unit := 1.0
+	switch {
+	case strings.HasSuffix(v, "mph"):
+		unit = 1.609344
+		v = strings.TrimSpace(strings.TrimSuffix(v, "mph"))
+	case strings.HasSuffix(v, "km/h"):
+		v = strings.TrimSpace(strings.TrimSuffix(v, "km/h"))
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 {
+		return 0
+	}
+	return f * unit
+}
+
+// readXML streams an .osm XML file into x.
+func readXML(r io.Reader, x *extract) error {
+	dec := xml.NewDecoder(r)
+	var (
+		inWay bool
+		refs  []int64
+		tags  map[string]string
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			attrs := map[string]string{}
+			for _, a := range t.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+			switch t.Name.Local {
+			case "node":
+				id, err1 := strconv.ParseInt(attrs["id"], 10, 64)
+				lat, err2 := strconv.ParseFloat(attrs["lat"], 64)
+				lon, err3 := strconv.ParseFloat(attrs["lon"], 64)
+				if err1 != nil || err2 != nil || err3 != nil {
+					return fmt.Errorf("node %q: invalid id or coordinates", attrs["id"])
+				}
+				x.node(id, lat, lon)
+			case "way":
+				inWay, refs, tags = true, nil, map[string]string{}
+			case "nd":
+				if inWay {
+					ref, err := strconv.ParseInt(attrs["ref"], 10, 64)
+					if err != nil {
+						return fmt.Errorf("nd ref %q: %w", attrs["ref"], err)
+					}
+					refs = append(refs, ref)
+				}
+			case "tag":
+				if inWay {
+					tags[attrs["k"]] = attrs["v"]
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "way" {
+				x.way(refs, tags)
+				inWay = false
+			}
+		}
+	}
+}
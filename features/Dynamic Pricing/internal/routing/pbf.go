@@ -0,0 +1,306 @@
+package routing
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Limits from the OSM PBF specification.
+const (
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+)
+
+// supportedFeatures are the required_features of an OSMHeader block readPBF understands.
+var supportedFeatures = map[string]bool{"OsmSchema-V0.6": true, "DenseNodes": true}
+
+// readPBF reads an .osm.pbf file into x: a sequence of (length, BlobHeader, Blob) triples whose OSMData
+// blobs hold zlib-compressed or raw PrimitiveBlocks.
+func readPBF(r io.Reader, x *extract) error {
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > maxBlobHeaderSize {
+			return errors.New("blob header too large")
+		}
+		hdr := make([]byte, n)
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return err
+		}
+		var typ string
+		var dataSize uint64
+		err := fields(hdr, func(num protowire.Number, v []byte, u uint64) error {
+			switch num {
+			case 1:
+				typ = string(v)
+			case 3:
+				dataSize = u
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if dataSize > maxBlobSize {
+			return errors.New("blob too large")
+		}
+		blob := make([]byte, dataSize)
+		if _, err := io.ReadFull(r, blob); err != nil {
+			return err
+		}
+		data, err := blobData(blob)
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case "OSMHeader":
+			err = checkHeader(data)
+		case "OSMData":
+			err = readBlock(data, x)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func blobData(blob []byte) ([]byte, error) {
+	var raw, zdata []byte
+	err := fields(blob, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			raw = v
+		case 3:
+			zdata = v
+		case 4, 5, 6, 7:
+			return errors.New("unsupported blob compression")
+		}
+		return nil
+	})
+	if err != nil || raw != nil {
+		return raw, err
+	}
+	if zdata == nil {
+		return nil, errors.New("empty blob")
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(zdata))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, maxBlobSize))
+}
+
+func checkHeader(data []byte) error {
+	return fields(data, func(num protowire.Number, v []byte, _ uint64) error {
+		if num == 4 && !supportedFeatures[string(v)] {
+			return fmt.Errorf("unsupported required feature %q", v)
+		}
+		return nil
+	})
+}
+
+// readBlock decodes a PrimitiveBlock. Coordinates are stored as offset + granularity * value nanodegrees.
+func readBlock(data []byte, x *extract) error {
+	var (
+		strs        []string
+		groups      [][]byte
+		granularity int64 = 100
+		latOffset   int64
+		lonOffset   int64
+	)
+	err := fields(data, func(num protowire.Number, v []byte, u uint64) error {
+		switch num {
+		case 1:
+			return fields(v, func(num protowire.Number, s []byte, _ uint64) error {
+				if num == 1 {
+					strs = append(strs, string(s))
+				}
+				return nil
+			})
+		case 2:
+			groups = append(groups, v)
+		case 17:
+			granularity = int64(u)
+		case 19:
+			latOffset = int64(u)
+		case 20:
+			lonOffset = int64(u)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	coord := func(offset, v int64) float64 { return 1e-9 * float64(offset+granularity*v) }
+	str := func(i uint64) string {
+		if i < uint64(len(strs)) {
+			return strs[i]
+		}
+		return ""
+	}
+
+	for _, g := range groups {
+		err := fields(g, func(num protowire.Number, v []byte, _ uint64) error {
+			switch num {
+			case 1:
+				return readNode(v, func(id, lat, lon int64) { x.node(id, coord(latOffset, lat), coord(lonOffset, lon)) })
+			case 2:
+				return readDense(v, func(id, lat, lon int64) { x.node(id, coord(latOffset, lat), coord(lonOffset, lon)) })
+			case 3:
+				return readWay(v, str, x)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readNode(v []byte, add func(id, lat, lon int64)) error {
+	var id, lat, lon int64
+	err := fields(v, func(num protowire.Number, _ []byte, u uint64) error {
+		switch num {
+		case 1:
+			id = protowire.DecodeZigZag(u)
+		case 8:
+			lat = protowire.DecodeZigZag(u)
+		case 9:
+			lon = protowire.DecodeZigZag(u)
+		}
+		return nil
+	})
+	if err == nil {
+		add(id, lat, lon)
+	}
+	return err
+}
+
+// readDense decodes DenseNodes: parallel delta-coded id, lat and lon arrays.
+func readDense(v []byte, add func(id, lat, lon int64)) error {
+	var ids, lats, lons []uint64
+	err := fields(v, func(num protowire.Number, b []byte, u uint64) error {
+		var err error
+		switch num {
+		case 1:
+			ids, err = appendPacked(ids, b, u)
+		case 8:
+			lats, err = appendPacked(lats, b, u)
+		case 9:
+			lons, err = appendPacked(lons, b, u)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(lats) != len(ids) || len(lons) != len(ids) {
+		return errors.New("dense nodes: id, lat and lon counts differ")
+	}
+	var id, lat, lon int64
+	for i := range ids {
+		id += protowire.DecodeZigZag(ids[i])
+		lat += protowire.DecodeZigZag(lats[i])
+		lon += protowire.DecodeZigZag(lons[i])
+		add(id, lat, lon)
+	}
+	return nil
+}
+
+func readWay(v []byte, str func(uint64) string, x *extract) error {
+	var keys, vals, refs []uint64
+	err := fields(v, func(num protowire.Number, b []byte, u uint64) error {
+		var err error
+		switch num {
+		case 2:
+			keys, err = appendPacked(keys, b, u)
+		case 3:
+			vals, err = appendPacked(vals, b, u)
+		case 8:
+			refs, err = appendPacked(refs, b, u)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(keys) != len(vals) {
+		return errors.New("way: key and value counts differ")
+	}
+	tags := make(map[string]string, len(keys))
+	for i := range keys {
+		tags[str(keys[i])] = str(vals[i])
+	}
+	ids := make([]int64, len(refs))
+	var id int64
+	for i, r := range refs {
+		id += protowire.DecodeZigZag(r)
+		ids[i] = id
+	}
+	x.way(ids, tags)
+	return nil
+}
+
+// fields calls fn for each field of a protobuf message: v for length-delimited fields, u for varints.
+func fields(b []byte, fn func(num protowire.Number, v []byte, u uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		var u uint64
+		switch typ {
+		case protowire.VarintType:
+			u, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		case protowire.Fixed32Type:
+			var u32 uint32
+			u32, n = protowire.ConsumeFixed32(b)
+			u = uint64(u32)
+		case protowire.Fixed64Type:
+			u, n = protowire.ConsumeFixed64(b)
+		default:
+			return fmt.Errorf("unsupported wire type %d", typ)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, v, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendPacked appends a repeated varint field, packed (b) or as a single element (u).
+func appendPacked(dst []uint64, b []byte, u uint64) ([]uint64, error) {
+	if b == nil {
+		return append(dst, u), nil
+	}
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		dst = append(dst, v)
+		b = b[n:]
+	}
+	return dst, nil
+}
@@ -0,0 +1,63 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+)
+
+// CHAStar is A* guided by the exact free-flow travel time to the target, read from a contraction
+// hierarchy. Like AStar it is exact for any weights that are at least the free-flow times, so it routes
+// around congestion the hierarchy knows nothing about, but its estimate is the road network's own rather
+// than the great circle at top speed, so it settles far fewer nodes.
+type CHAStar struct{ H *CH }
+
+func (CHAStar) Name() string { return "ch-astar" }
+
+func (a CHAStar) ShortestPath(from, to int32, w WeightFunc) (Path, bool) {
+	return search(a.H.Graph, from, to, w, a.H.potential(to))
+}
+
+// potential returns the free-flow travel time from any node to to, +Inf where to cannot be reached. A
+// backward search up the hierarchy from to finds the time to it from every node it climbs through; the
+// time from n is then the least over the upward arcs of n of the arc plus the time from its head, or the
+// backward distance of n itself, worked out on first use and kept.
+func (h *CH) potential(to int32) func(int32) float64 {
+	n := len(h.Rank)
+	bwd := make([]float64, n)
+	for i := range bwd {
+		bwd[i] = math.Inf(1)
+	}
+	bwd[to] = 0
+	pq := &nodeQueue{{node: to}}
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(queued)
+		u := it.node
+		if it.cost > bwd[u] {
+			continue
+		}
+		for i := h.DownFirst[u]; i < h.DownFirst[u+1]; i++ {
+			a := h.Down[i]
+			if d := it.cost + a.Seconds; d < bwd[a.To] {
+				bwd[a.To] = d
+				heap.Push(pq, queued{node: a.To, cost: d, key: d})
+			}
+		}
+	}
+
+	known := make([]bool, n)
+	pot := make([]float64, n)
+	var from func(int32) float64
+	from = func(u int32) float64 {
+		if known[u] {
+			return pot[u]
+		}
+		// Upward arcs only lead to higher ranks, so the recursion ends at the top of the hierarchy.
+		best := bwd[u]
+		for i := h.UpFirst[u]; i < h.UpFirst[u+1]; i++ {
+			best = math.Min(best, h.Up[i].Seconds+from(h.Up[i].To))
+		}
+		known[u], pot[u] = true, best
+		return best
+	}
+	return from
+}
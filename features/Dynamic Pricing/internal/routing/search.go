@@ -0,0 +1,170 @@
+package routing
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WeightFunc is the travel time in seconds of e from node from, entered elapsed seconds after departure;
+// math.Inf(1) closes the edge. Searches are exact for weights that never let a later entry leave e earlier.
+type WeightFunc func(from int32, e *Edge, elapsed float64) float64
+
+// FreeFlow weighs edges by their free-flow travel time.
+func FreeFlow(_ int32, e *Edge, _ float64) float64 { return e.Seconds }
+
+// Path is a route through a graph with the statistics of the search that found it.
+type Path struct {
+	Nodes    []int32
+	Cost     float64 // sum of the edge weights
+	Meters   float64
+	Seconds  float64 // free-flow travel time
+	Expanded int     // nodes settled by the search
+	Elapsed  time.Duration
+}
+
+// Searcher finds a least-cost path between two nodes. ok is false when to is unreachable.
+type Searcher interface {
+	Name() string
+	ShortestPath(from, to int32, w WeightFunc) (p Path, ok bool)
+}
+
+var ErrUnknownAlgorithm = errors.New("unknown routing algorithm")
+
+// NewSearcher returns the searcher called name over g: "astar" or "dijkstra".
+func NewSearcher(name string, g *Graph) (Searcher, error) {
+	switch name {
+	case "astar":
+		return AStar{G: g}, nil
+	case "dijkstra":
+		return Dijkstra{G: g}, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownAlgorithm, name)
+}
+
+// Dijkstra searches outwards from the source by cost and stops when it settles the target.
+type Dijkstra struct{ G *Graph }
+
+func (Dijkstra) Name() string { return "dijkstra" }
+
+func (d Dijkstra) ShortestPath(from, to int32, w WeightFunc) (Path, bool) {
+	return search(d.G, from, to, w, nil)
+}
+
+// search is best-first search from one node to another, ordered by cost so far plus h (nil for Dijkstra).
+// h must be consistent, so that a node is final when first settled.
+func search(g *Graph, from, to int32, w WeightFunc, h func(int32) float64) (Path, bool) {
+	start := time.Now()
+	t := grow(g, from, w, h, func(u int32) bool { return u == to })
+	p, ok := t.path(g, from, to)
+	p.Elapsed = time.Since(start)
+	return p, ok
+}
+
+// OneToMany returns the fastest path from from to each of targets, found with a single Dijkstra search
+// that stops once every target is settled. ok[i] is false when targets[i] is unreachable. Expanded and
+// Elapsed are those of the whole search, on every path.
+func OneToMany(g *Graph, from int32, targets []int32, w WeightFunc) (paths []Path, ok []bool) {
+	start := time.Now()
+	pending := map[int32]bool{}
+	for _, t := range targets {
+		pending[t] = true
+	}
+	t := grow(g, from, w, nil, func(u int32) bool {
+		delete(pending, u)
+		return len(pending) == 0
+	})
+	elapsed := time.Since(start)
+	paths, ok = make([]Path, len(targets)), make([]bool, len(targets))
+	for i, to := range targets {
+		paths[i], ok[i] = t.path(g, from, to)
+		paths[i].Elapsed = elapsed
+	}
+	return paths, ok
+}
+
+// tree is the result of a search: the cost of and the edge into every node it reached.
+type tree struct {
+	dist     []float64
+	prev     []int32
+	via      []int32 // index into g.Edges of the edge that reached the node
+	expanded int
+}
+
+// grow searches from from until done returns true for a settled node or every reachable node is settled.
+func grow(g *Graph, from int32, w WeightFunc, h func(int32) float64, done func(int32) bool) *tree {
+	t := &tree{dist: make([]float64, len(g.Nodes)), prev: make([]int32, len(g.Nodes)), via: make([]int32, len(g.Nodes))}
+	for i := range t.dist {
+		t.dist[i] = math.Inf(1)
+		t.prev[i] = -1
+	}
+	estimate := func(n int32, cost float64) float64 {
+		if h == nil {
+			return cost
+		}
+		return cost + h(n)
+	}
+	t.dist[from] = 0
+	pq := &nodeQueue{{node: from, key: estimate(from, 0)}}
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(queued)
+		u := it.node
+		if it.cost > t.dist[u] {
+			continue
+		}
+		t.expanded++
+		if done(u) {
+			break
+		}
+		for i := g.First[u]; i < g.First[u+1]; i++ {
+			e := &g.Edges[i]
+			d := t.dist[u] + w(u, e, t.dist[u])
+			if d < t.dist[e.To] {
+				t.dist[e.To], t.prev[e.To], t.via[e.To] = d, u, i
+				heap.Push(pq, queued{node: e.To, cost: d, key: estimate(e.To, d)})
+			}
+		}
+	}
+	return t
+}
+
+// path walks the tree back from to.
+func (t *tree) path(g *Graph, from, to int32) (Path, bool) {
+	if math.IsInf(t.dist[to], 1) {
+		return Path{Expanded: t.expanded}, false
+	}
+	p := Path{Cost: t.dist[to], Expanded: t.expanded}
+	for n := to; n != from; n = t.prev[n] {
+		p.Nodes = append(p.Nodes, n)
+		p.Meters += g.Edges[t.via[n]].Meters
+		p.Seconds += g.Edges[t.via[n]].Seconds
+	}
+	p.Nodes = append(p.Nodes, from)
+	for i, j := 0, len(p.Nodes)-1; i < j; i, j = i+1, j-1 {
+		p.Nodes[i], p.Nodes[j] = p.Nodes[j], p.Nodes[i]
+	}
+	return p, true
+}
+
+type queued struct {
+	node int32
+	cost float64 // cost so far
+	key  float64 // queue order: cost plus heuristic
+}
+
+// nodeQueue is a min-heap of nodes by key; nodes are pushed again when their cost drops, and stale
+// entries skipped when popped.
+type nodeQueue []queued
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].key < q[j].key }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(queued)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
@@ -12,6 +12,14 @@ import (
 
 var jwtKey = []byte("default-secret")
 
+// Token types, carried in the typ claim so that one kind of token is never accepted as another.
+const (
+	tokenTypeLogin = "login"
+	tokenTypeQuote = "quote"
+)
+
+var ErrWrongTokenType = errors.New("wrong token type")
+
 func RegisterUser(db *gorm.DB, u *models.User) error {
 	if u.Email == "" || u.Password == "" {
 		return errors.New("email and password required")
@@ -37,6 +45,7 @@ func LoginUser(db *gorm.DB, email, password string, cfg *config.Config) (string,
 		"email": u.Email,
 		"uid":   u.ID,
 		"role":  u.Role,
+		"typ":   tokenTypeLogin,
 		"exp":   time.Now().Add(24 * time.Hour).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -45,14 +54,26 @@ func LoginUser(db *gorm.DB, email, password string, cfg *config.Config) (string,
 }
 
 func ValidateToken(tokenStr string, cfg *config.Config) (bool, error) {
+	if _, err := ParseToken(tokenStr, cfg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ParseToken validates a login token and returns its claims (email, uid, role).
+func ParseToken(tokenStr string, cfg *config.Config) (jwt.MapClaims, error) {
 	if cfg != nil && cfg.JWT.Secret != "" {
 		jwtKey = []byte(cfg.JWT.Secret)
 	}
-	_, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
 		return jwtKey, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	if claims["typ"] != tokenTypeLogin {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
 }
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"VOID/config"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"gorm.io/gorm"
+)
+
+// ZoneDemandIndex returns the zone of (lat, lon) and its pending-orders / available-vehicles ratio,
+// smoothed with the surrounding cells weighted by cfg.Zones.NeighborWeight.
+func ZoneDemandIndex(db *gorm.DB, cfg *config.Config, lat, lon float64) (string, float64) {
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	center := grid.CellOf(lat, lon)
+	minLat, minLon, maxLat, maxLon := grid.Bounds(center, 1)
+
+	var orders []models.Order
+	_ = db.Select("pickup_lat", "pickup_lon").
+		Where("status = ? AND pickup_lat >= ? AND pickup_lat < ? AND pickup_lon >= ? AND pickup_lon < ?",
+			config.OrderStatusPending, minLat, maxLat, minLon, maxLon).
+		Find(&orders).Error
+	var vehicles []models.Vehicle
+	_ = db.Select("latitude", "longitude").
+		Where("status = ? AND latitude >= ? AND latitude < ? AND longitude >= ? AND longitude < ?",
+			"available", minLat, maxLat, minLon, maxLon).
+		Find(&vehicles).Error
+
+	pending, avail := binDemand(grid, orders, vehicles)
+	return grid.ID(center), smoothedDemand(center, pending, avail, cfg.Zones.NeighborWeight)
+}
+
+// ZoneDemandIndices computes the smoothed demand index of every zone that has pending orders in or
+// next to it. Zones missing from the result have an index of 0.
+func ZoneDemandIndices(db *gorm.DB, cfg *config.Config) map[string]float64 {
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	var orders []models.Order
+	_ = db.Select("pickup_lat", "pickup_lon").Where("status = ?", config.OrderStatusPending).Find(&orders).Error
+	var vehicles []models.Vehicle
+	_ = db.Select("latitude", "longitude").Where("status = ?", "available").Find(&vehicles).Error
+
+	pending, avail := binDemand(grid, orders, vehicles)
+	out := map[string]float64{}
+	for c := range pending {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				n := geo.Cell{Row: c.Row + dr, Col: c.Col + dc}
+				id := grid.ID(n)
+				if _, done := out[id]; !done {
+					out[id] = smoothedDemand(n, pending, avail, cfg.Zones.NeighborWeight)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func binDemand(grid geo.Grid, orders []models.Order, vehicles []models.Vehicle) (pending, avail map[geo.Cell]int) {
+	pending = map[geo.Cell]int{}
+	avail = map[geo.Cell]int{}
+	for _, o := range orders {
+		pending[grid.CellOf(o.PickupLat, o.PickupLon)]++
+	}
+	for _, v := range vehicles {
+		avail[grid.CellOf(v.Latitude, v.Longitude)]++
+	}
+	return pending, avail
+}
+
+// smoothedDemand is pending/(available+1) over center and its 8 neighbours, the neighbours weighted by neighborWeight.
+func smoothedDemand(center geo.Cell, pending, avail map[geo.Cell]int, neighborWeight float64) float64 {
+	var p, a float64
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			c := geo.Cell{Row: center.Row + dr, Col: center.Col + dc}
+			w := neighborWeight
+			if dr == 0 && dc == 0 {
+				w = 1
+			}
+			p += w * float64(pending[c])
+			a += w * float64(avail[c])
+		}
+	}
+	return p / (a + 1.0)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/elasticity"
+	"VOID/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExpireQuotes resolves the quotes that expired unredeemed before now.
+func ExpireQuotes(db *gorm.DB, now time.Time) (int64, error) {
+	res := db.Model(&models.PricingHistory{}).
+		Where("outcome = ? AND expires_at < ?", models.OutcomePending, now).
+		Updates(map[string]interface{}{"outcome": models.OutcomeExpired, "outcome_at": now})
+	return res.RowsAffected, res.Error
+}
+
+// resolveOrderOutcome moves the pricing calls that became order orderID to outcome.
+func resolveOrderOutcome(tx *gorm.DB, orderID uint, outcome string) error {
+	return tx.Model(&models.PricingHistory{}).
+		Where("order_id = ? AND outcome IN ?", orderID, []string{models.OutcomePending, models.OutcomeConverted}).
+		Updates(map[string]interface{}{"outcome": outcome, "outcome_at": time.Now()}).Error
+}
+
+// EstimateElasticity expires stale quotes, then refits the conversion curve of every zone and hour band
+// from the quotes of the last cfg.Elasticity.Window that have an outcome, dropping the estimates of segments
+// without any. A quote converts when it became an order that was not cancelled.
+func EstimateElasticity(db *gorm.DB, cfg *config.Config, now time.Time) ([]models.ElasticityEstimate, error) {
+	if _, err := ExpireQuotes(db, now); err != nil {
+		return nil, err
+	}
+	ec := cfg.Elasticity
+	start := now.Add(-ec.Window)
+	var rows []models.PricingHistory
+	// Direct orders always convert; only quotes tell how the price affected the decision.
+	err := db.Select("zone", "created_at", "price_multiplier", "outcome").
+		Where("created_at >= ? AND quote_id <> '' AND outcome IN ?", start,
+			[]string{models.OutcomeConverted, models.OutcomeCanceled, models.OutcomeExpired}).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	loc := cfg.Live().Pricing.Location()
+	type segment struct{ zone, band string }
+	samples := map[segment][]elasticity.Sample{}
+	for _, r := range rows {
+		k := segment{r.Zone, hourBand(ec.HourBands, r.CreatedAt.In(loc))}
+		samples[k] = append(samples[k], elasticity.Sample{Multiplier: r.PriceMultiplier, Converted: r.Outcome == models.OutcomeConverted})
+	}
+
+	params := elasticity.Params{Width: ec.BucketWidth, MinSamples: ec.MinSamples, MaxDrop: ec.Guardrail.MaxConversionDrop}
+	var fitted []models.ElasticityEstimate
+	for k, s := range samples {
+		est := elasticity.Fit(s, params)
+		fitted = append(fitted, models.ElasticityEstimate{
+			Zone: k.zone, Band: k.band, Samples: est.Samples, Converted: est.Converted,
+			Baseline: est.Baseline, Elasticity: est.Elasticity, SurgeCap: est.Cap, Buckets: est.Buckets,
+			WindowStart: start, FittedAt: now,
+		})
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// A segment without quotes in the window would otherwise keep enforcing the cap of its last fit.
+		var stored []models.ElasticityEstimate
+		if err := tx.Select("id", "zone", "band").Find(&stored).Error; err != nil {
+			return err
+		}
+		var stale []uint
+		for _, e := range stored {
+			if _, ok := samples[segment{e.Zone, e.Band}]; !ok {
+				stale = append(stale, e.ID)
+			}
+		}
+		if len(stale) > 0 {
+			if err := tx.Delete(&models.ElasticityEstimate{}, stale).Error; err != nil {
+				return err
+			}
+		}
+		if len(fitted) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "zone"}, {Name: "band"}}, UpdateAll: true}).Create(&fitted).Error
+	})
+	return fitted, err
+}
+
+// RunElasticityEstimates refits the conversion curves every cfg.Elasticity.Interval, starting immediately.
+func RunElasticityEstimates(db *gorm.DB, cfg *config.Config) {
+	for {
+		if fitted, err := EstimateElasticity(db, cfg, time.Now()); err != nil {
+			log.Printf("elasticity estimate: %v", err)
+		} else {
+			log.Printf("elasticity estimate: %d segments", len(fitted))
+		}
+		time.Sleep(cfg.Elasticity.Interval)
+	}
+}
+
+// ElasticityReport returns the stored estimates, of one zone if zone is not empty.
+func ElasticityReport(db *gorm.DB, zone string) ([]models.ElasticityEstimate, error) {
+	q := db.Order("zone, band")
+	if zone != "" {
+		q = q.Where("zone = ?", zone)
+	}
+	var out []models.ElasticityEstimate
+	return out, q.Find(&out).Error
+}
+
+// surgeCap is the guardrail on the surge multiplier in zone at t; 0 when the guardrail is off or conversion
+// holds up there.
+func surgeCap(db *gorm.DB, cfg *config.Config, zone string, t time.Time) float64 {
+	if !cfg.Elasticity.Guardrail.Enabled {
+		return 0
+	}
+	var est models.ElasticityEstimate
+	err := db.Select("surge_cap").Where("zone = ? AND band = ?", zone, hourBand(cfg.Elasticity.HourBands, t.In(cfg.Live().Pricing.Location()))).
+		Limit(1).Find(&est).Error
+	if err != nil {
+		return 0
+	}
+	return est.SurgeCap
+}
+
+// hourBand labels the band of bands (ascending start hours from 0) that the hour of t, in t's location,
+// falls in. Callers pass t in the pricing time zone so that bands do not move with the server's.
+func hourBand(bands []int, t time.Time) string {
+	h := t.Hour()
+	i := len(bands) - 1
+	for i > 0 && bands[i] > h {
+		i--
+	}
+	end := 24
+	if i+1 < len(bands) {
+		end = bands[i+1]
+	}
+	return fmt.Sprintf("%02d-%02d", bands[i], end)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"gorm.io/gorm"
+)
+
+type VariantReport struct {
+	Variant          string  `json:"variant"`
+	Priced           int     `json:"priced"` // distinct quotes and direct orders priced
+	AvgPrice         float64 `json:"avg_price"`
+	Quotes           int     `json:"quotes"`
+	QuoteOrders      int     `json:"quote_orders"`  // quotes redeemed for an order
+	DirectOrders     int     `json:"direct_orders"` // orders placed without a quote; always "converted"
+	Orders           int     `json:"orders"`        // quote_orders + direct_orders
+	Canceled         int     `json:"canceled"`
+	ConversionRate   float64 `json:"conversion_rate"`   // quote_orders / quotes
+	CancellationRate float64 `json:"cancellation_rate"` // canceled / orders
+}
+
+// ExperimentReport aggregates PricingHistory per variant of the named experiment. Re-pricings of existing
+// orders are left out, and a quote and the order created from it count as one pricing attempt. Only
+// quotes can fail to convert, so the conversion rate is over quotes and direct orders are counted apart.
+func ExperimentReport(db *gorm.DB, experiment string) ([]VariantReport, error) {
+	var rows []models.PricingHistory
+	if err := db.Select("id", "variant", "quote_id", "order_id", "final_price_minor", "final_price_currency").
+		Where("experiment = ? AND COALESCE(outcome, '') <> ?", experiment, models.OutcomeRepriced).
+		Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	type attempt struct {
+		variant string
+		orderID uint
+		quoted  bool
+		price   float64
+	}
+	// Attempts are keyed by order once there is one, else by quote.
+	attempts := map[string]*attempt{}
+	orderIDs := []uint{}
+	for _, r := range rows {
+		key := "q:" + r.QuoteID
+		if r.OrderID != 0 {
+			key = fmt.Sprintf("o:%d", r.OrderID)
+		}
+		a, ok := attempts[key]
+		if !ok {
+			a = &attempt{variant: r.Variant, price: r.FinalPrice.Float64()}
+			attempts[key] = a
+		}
+		if r.QuoteID != "" && !a.quoted {
+			a.quoted = true
+			a.price = r.FinalPrice.Float64()
+		}
+		if r.OrderID != 0 && a.orderID == 0 {
+			a.orderID = r.OrderID
+			orderIDs = append(orderIDs, r.OrderID)
+		}
+	}
+
+	canceled := map[uint]bool{}
+	if len(orderIDs) > 0 {
+		var orders []models.Order
+		if err := db.Select("id", "status").Where("id IN ?", orderIDs).Find(&orders).Error; err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			canceled[o.ID] = o.Status == config.OrderStatusCanceled
+		}
+	}
+
+	byVariant := map[string]*VariantReport{}
+	for _, a := range attempts {
+		vr, ok := byVariant[a.variant]
+		if !ok {
+			vr = &VariantReport{Variant: a.variant}
+			byVariant[a.variant] = vr
+		}
+		vr.Priced++
+		vr.AvgPrice += a.price
+		if a.quoted {
+			vr.Quotes++
+		}
+		if a.orderID != 0 {
+			if a.quoted {
+				vr.QuoteOrders++
+			} else {
+				vr.DirectOrders++
+			}
+			vr.Orders++
+			if canceled[a.orderID] {
+				vr.Canceled++
+			}
+		}
+	}
+	out := make([]VariantReport, 0, len(byVariant))
+	for _, vr := range byVariant {
+		vr.AvgPrice = round2(vr.AvgPrice / float64(vr.Priced))
+		vr.ConversionRate = ratio(vr.QuoteOrders, vr.Quotes)
+		vr.CancellationRate = ratio(vr.Canceled, vr.Orders)
+		out = append(out, *vr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Variant < out[j].Variant })
+	return out, nil
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -3,13 +3,16 @@ package services
 import (
 	"VOID/config"
 	"errors"
+	"log"
 	"math"
 
 	"VOID/internal/models"
 	"gorm.io/gorm"
 )
 
-func AssignNearestDriver(db *gorm.DB, orderID uint) (*models.Vehicle, error) {
+// AssignNearestDriver assigns the order to the closest available vehicle and stores the order's
+// pickup-to-dropoff route. Failing to route the order does not fail the assignment.
+func AssignNearestDriver(db *gorm.DB, orderID uint, cfg *config.Config) (*models.Vehicle, error) {
 	var o models.Order
 	if err := db.First(&o, orderID).Error; err != nil {
 		return nil, err
@@ -44,6 +47,9 @@ func AssignNearestDriver(db *gorm.DB, orderID uint) (*models.Vehicle, error) {
 	if err := db.Save(&o).Error; err != nil {
 		return nil, err
 	}
+	if _, err := StoreOrderRoute(db, o.ID, cfg); err != nil && !errors.Is(err, ErrNoRoadGraph) {
+		log.Printf("order %d route: %v", o.ID, err)
+	}
 	return best, nil
 }
 
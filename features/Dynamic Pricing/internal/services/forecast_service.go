@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/forecast"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoForecast = errors.New("no forecast model for zone")
+
+// FitDemandForecasts refits the demand model of every zone with at least cfg.Forecast.MinOrders orders
+// in the last cfg.Forecast.History, on hourly counts up to the last complete hour before now. The models
+// of the other zones are dropped.
+func FitDemandForecasts(db *gorm.DB, cfg *config.Config, now time.Time) ([]models.DemandForecast, error) {
+	fc := cfg.Forecast
+	end := now.Truncate(time.Hour)
+	start := end.Add(-fc.History)
+	hours := int(fc.History / time.Hour)
+	if hours < 2*fc.Season {
+		return nil, forecast.ErrInsufficientData
+	}
+
+	var orders []models.Order
+	err := db.Unscoped().Select("created_at", "pickup_lat", "pickup_lon").
+		Where("created_at >= ? AND created_at < ?", start, end).Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	series := map[string][]float64{}
+	for _, o := range orders {
+		z := grid.ZoneID(o.PickupLat, o.PickupLon)
+		if series[z] == nil {
+			series[z] = make([]float64, hours)
+		}
+		series[z][int(o.CreatedAt.Sub(start)/time.Hour)]++
+	}
+
+	var fitted []models.DemandForecast
+	for zone, ys := range series {
+		total := 0
+		for _, y := range ys {
+			total += int(y)
+		}
+		if total < fc.MinOrders {
+			continue
+		}
+		m, err := forecast.Fit(ys, fc.Season, end.Add(-time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		fitted = append(fitted, models.DemandForecast{
+			Zone: zone, Season: m.Season, Alpha: m.Alpha, Beta: m.Beta, Gamma: m.Gamma,
+			Level: m.Level, Trend: m.Trend, Seasonal: m.Seasonal, Sigma: m.Sigma, LastAt: m.LastAt,
+			Observations: total, FittedAt: now,
+		})
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		zones := make([]string, len(fitted))
+		for i, f := range fitted {
+			zones[i] = f.Zone
+		}
+		// Zones that fell below MinOrders would otherwise keep pricing from a model extrapolated further every hour.
+		stale := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		if len(zones) > 0 {
+			stale = tx.Where("zone NOT IN ?", zones)
+		}
+		if err := stale.Delete(&models.DemandForecast{}).Error; err != nil {
+			return err
+		}
+		if len(fitted) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "zone"}}, UpdateAll: true}).Create(&fitted).Error
+	})
+	return fitted, err
+}
+
+// RunForecastRefits refits the demand models every cfg.Forecast.RefitInterval, starting immediately.
+func RunForecastRefits(db *gorm.DB, cfg *config.Config) {
+	for {
+		if fitted, err := FitDemandForecasts(db, cfg, time.Now()); err != nil {
+			log.Printf("demand forecast refit: %v", err)
+		} else {
+			log.Printf("demand forecast refit: %d zones", len(fitted))
+		}
+		time.Sleep(cfg.Forecast.RefitInterval)
+	}
+}
+
+// ZoneForecast returns the forecast of zone for the hours hours after now, with prediction intervals
+// at the given coverage.
+func ZoneForecast(db *gorm.DB, zone string, hours int, level float64, now time.Time) ([]forecast.Point, *models.DemandForecast, error) {
+	var row models.DemandForecast
+	if err := db.Where("zone = ?", zone).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrNoForecast
+		}
+		return nil, nil, err
+	}
+	return forecastModel(&row).ForecastFrom(now, hours, level), &row, nil
+}
+
+// forecastDemand is the expected number of orders in zone over the hour after now; 0 without a model.
+func forecastDemand(db *gorm.DB, zone string, now time.Time) float64 {
+	points, _, err := ZoneForecast(db, zone, 1, 0.5, now)
+	if err != nil || len(points) == 0 {
+		return 0
+	}
+	return points[0].Mean
+}
+
+func forecastModel(row *models.DemandForecast) *forecast.Model {
+	return &forecast.Model{
+		Season: row.Season, Alpha: row.Alpha, Beta: row.Beta, Gamma: row.Gamma,
+		Level: row.Level, Trend: row.Trend, Seasonal: row.Seasonal, Sigma: row.Sigma, LastAt: row.LastAt,
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/invoice"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+	"VOID/internal/tax"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrderNotInvoiceable = errors.New("only delivered orders are invoiced")
+	ErrInvoiceNotIssued    = errors.New("no invoice has been issued for the order")
+)
+
+// applyOrderTax stores the tax lines of o, priced at b, and sets o.Tax. o must be persisted; the caller saves it.
+func applyOrderTax(tx *gorm.DB, o *models.Order, b pricing.Breakdown, cfg *config.Config) error {
+	mode, err := money.ParseRoundingMode(cfg.Live().Pricing.Rounding)
+	if err != nil {
+		return err
+	}
+	lines := tax.NewRules(cfg.Tax, mode).Apply(b, o.Category)
+	if err := tx.Where("order_id = ?", o.ID).Delete(&models.TaxLine{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.TaxLine, len(lines))
+	for i, l := range lines {
+		rows[i] = models.TaxLine{OrderID: o.ID, Position: i, Component: l.Component, Tax: l.Tax, Rate: l.Rate, Taxable: l.Taxable, Amount: l.Amount}
+	}
+	if len(rows) > 0 {
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+	}
+	o.Tax = tax.Total(lines, b.Total.Currency)
+	return nil
+}
+
+// issueInvoice issues the next invoice number of the current series to o, which has just been delivered,
+// unless it already has one. Amounts are fixed when the invoice is issued.
+func issueInvoice(tx *gorm.DB, o *models.Order, cfg *config.Config, now time.Time) error {
+	// An invoice number, once issued, is never voided, so orders that may still be cancelled get none.
+	if o.Status != config.OrderStatusDelivered {
+		return ErrOrderNotInvoiceable
+	}
+	var inv models.Invoice
+	if err := tx.Where("order_id = ?", o.ID).Limit(1).Find(&inv).Error; err != nil || inv.ID != 0 {
+		return err
+	}
+	number, err := nextInvoiceNumber(tx, cfg.Tax.Invoice.Prefix, now)
+	if err != nil {
+		return err
+	}
+	inv = models.Invoice{
+		Number: number, OrderID: o.ID, Subtotal: o.ComputedPrice, Tax: o.Tax, Total: o.ComputedPrice,
+		Inclusive: cfg.Tax.Inclusive, IssuedAt: now,
+	}
+	if !inv.Inclusive {
+		inv.Total = inv.Total.Add(o.Tax)
+	}
+	return tx.Create(&inv).Error
+}
+
+// IssueInvoice issues the invoice of a delivered order that has none, e.g. one delivered before invoices
+// were issued on delivery.
+func IssueInvoice(db *gorm.DB, orderID uint, cfg *config.Config, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var o models.Order
+		if err := tx.First(&o, orderID).Error; err != nil {
+			return err
+		}
+		return issueInvoice(tx, &o, cfg, now)
+	})
+}
+
+// OrderInvoice returns the invoice document of a delivered order as issued on delivery. userID is the
+// customer asking, who must have placed the order; 0 lets an admin read any invoice.
+func OrderInvoice(db *gorm.DB, orderID, userID uint, cfg *config.Config) (*invoice.Document, error) {
+	var (
+		o     models.Order
+		inv   models.Invoice
+		lines []models.TaxLine
+	)
+	if err := db.First(&o, orderID).Error; err != nil {
+		return nil, err
+	}
+	if userID != 0 && o.UserID != userID {
+		return nil, ErrNotOrderOwner
+	}
+	if o.Status != config.OrderStatusDelivered {
+		return nil, ErrOrderNotInvoiceable
+	}
+	if err := db.Where("order_id = ?", o.ID).Limit(1).Find(&inv).Error; err != nil {
+		return nil, err
+	}
+	if inv.ID == 0 {
+		return nil, ErrInvoiceNotIssued
+	}
+	if err := db.Where("order_id = ?", o.ID).Order("position").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	doc := &invoice.Document{
+		Number:   inv.Number,
+		IssuedAt: inv.IssuedAt,
+		Seller: invoice.Party{
+			Name: cfg.Tax.Invoice.Seller, Address: cfg.Tax.Invoice.Address, TaxID: cfg.Tax.Invoice.TaxID,
+		},
+		OrderID:   o.ID,
+		OrderedAt: o.CreatedAt,
+		Category:  o.Category,
+		TaxLines:  lines,
+		Inclusive: inv.Inclusive,
+		Subtotal:  inv.Subtotal,
+		Tax:       inv.Tax,
+		Total:     inv.Total,
+	}
+	var u models.User
+	if err := db.Limit(1).Find(&u, o.UserID).Error; err == nil {
+		doc.Customer = invoice.Party{Name: u.Name, Address: u.Email}
+		if doc.Customer.Name == "" {
+			doc.Customer.Name = u.Email
+		}
+	}
+	var ph models.PricingHistory
+	if err := orderPricing(db, o.ID, &ph); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	for _, c := range ph.Components {
+		if c.Side == models.SideFee && !c.Amount.IsZero() {
+			doc.Items = append(doc.Items, invoice.Item{Component: c.Name, Amount: c.Amount})
+		}
+	}
+	if len(doc.Items) == 0 {
+		doc.Items = []invoice.Item{{Component: "delivery", Amount: inv.Subtotal}}
+	}
+	doc.Summarize()
+	return doc, nil
+}
+
+// nextInvoiceNumber takes the next number of the prefix's series for the year of now, e.g. INV-2026-000042.
+func nextInvoiceNumber(tx *gorm.DB, prefix string, now time.Time) (string, error) {
+	series := fmt.Sprintf("%s%d", prefix, now.Year())
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceSequence{Series: series}).Error
+	if err != nil {
+		return "", err
+	}
+	err = tx.Model(&models.InvoiceSequence{}).Where("series = ?", series).Update("last", gorm.Expr("last + 1")).Error
+	if err != nil {
+		return "", err
+	}
+	var seq models.InvoiceSequence
+	if err := tx.First(&seq, "series = ?", series).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%06d", series, seq.Last), nil
+}
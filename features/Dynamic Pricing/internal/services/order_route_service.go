@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoOrderRoute = errors.New("no route stored for order")
+
+// StoreOrderRoute computes the route of an order from pickup to dropoff leaving now and stores it as the
+// order's route, replacing any earlier one.
+func StoreOrderRoute(db *gorm.DB, orderID uint, cfg *config.Config) (*models.Route, error) {
+	var o models.Order
+	if err := db.First(&o, orderID).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	r, err := ComputeOptimalRoute(db, o.PickupLat, o.PickupLon, o.DropoffLat, o.DropoffLon, "", now, cfg)
+	if err != nil {
+		return nil, err
+	}
+	path := make([][2]float64, len(r.Path))
+	for i, p := range r.Path {
+		path[i] = [2]float64{p.Lat, p.Lon}
+	}
+	row := models.Route{
+		OrderID: o.ID, Polyline: geo.EncodePolyline(path), Meters: r.Meters, Seconds: r.Seconds, FreeFlow: r.FreeFlow,
+		Algorithm: r.Algorithm, GraphVersion: roadGraph.Version(), ComputedAt: now,
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "order_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"updated_at", "polyline", "meters", "seconds", "free_flow", "algorithm", "graph_version", "computed_at",
+		}),
+	}).Create(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// OrderRoute returns the stored route of an order.
+func OrderRoute(db *gorm.DB, orderID uint) (*models.Route, error) {
+	var r models.Route
+	if err := db.Where("order_id = ?", orderID).Limit(1).Find(&r).Error; err != nil {
+		return nil, err
+	}
+	if r.ID == 0 {
+		return nil, ErrNoOrderRoute
+	}
+	return &r, nil
+}
@@ -2,11 +2,27 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"VOID/config"
+	"VOID/internal/cache"
 	"VOID/internal/models"
+	"VOID/internal/pricing"
 	"gorm.io/gorm"
 )
 
+// MaxWaitMinutes bounds the wait a driver can report on delivery: a day.
+const MaxWaitMinutes = 24 * 60
+
+var (
+	ErrOrderNotCancelable = errors.New("order can no longer be canceled")
+	ErrOrderNotAssigned   = errors.New("order is not assigned to a driver")
+	ErrNotOrderDriver     = errors.New("order is assigned to another driver")
+	ErrNotOrderOwner      = errors.New("order belongs to another user")
+	ErrWaitTooLong        = fmt.Errorf("wait_minutes must be between 0 and %d", MaxWaitMinutes)
+)
+
 func CreateOrder(db *gorm.DB, o *models.Order) error {
 	if o.UserID == 0 {
 		return errors.New("user required")
@@ -14,6 +30,162 @@ func CreateOrder(db *gorm.DB, o *models.Order) error {
 	return db.Create(o).Error
 }
 
+// PlaceOrder creates o, prices it and reserves promoCode (if any) in one transaction,
+// so an order is never stored with a promotion it could not redeem.
+func PlaceOrder(db *gorm.DB, o *models.Order, promoCode string, cacheClient *cache.Cache, cfg *config.Config) (*pricing.Breakdown, error) {
+	var p *pricedOrder
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateOrder(tx, o); err != nil {
+			return err
+		}
+		var err error
+		if p, err = priceOrder(tx, o, cacheClient, cfg); err != nil {
+			return err
+		}
+		if promoCode != "" {
+			if err := reservePromotion(tx, promoCode, p, o.ID, "", nil); err != nil {
+				return err
+			}
+		}
+		o.ComputedPrice = p.Breakdown.Total
+		o.DriverPayout = p.Payout.Total
+		o.PromoCode = p.PromoCode
+		if err := applyOrderTax(tx, o, p.Breakdown, cfg); err != nil {
+			return err
+		}
+		if err := tx.Save(o).Error; err != nil {
+			return err
+		}
+		ph := newPricingHistory(p)
+		ph.Outcome, ph.OutcomeAt = models.OutcomeConverted, &ph.CreatedAt
+		return tx.Create(ph).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p.Breakdown, nil
+}
+
+// CancelOrder cancels a pending or assigned order, frees its vehicle and releases its promotion. userID is
+// the customer cancelling, who must have placed the order; 0 lets an admin cancel any order.
+func CancelOrder(db *gorm.DB, id, userID uint) (*models.Order, error) {
+	var o models.Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&o, id).Error; err != nil {
+			return err
+		}
+		if userID != 0 && o.UserID != userID {
+			return ErrNotOrderOwner
+		}
+		if o.Status != config.OrderStatusPending && o.Status != config.OrderStatusAssigned {
+			return ErrOrderNotCancelable
+		}
+		if o.AssignedToID != nil {
+			if err := tx.Model(&models.Vehicle{}).Where("id = ?", *o.AssignedToID).Update("status", "available").Error; err != nil {
+				return err
+			}
+		}
+		o.Status = config.OrderStatusCanceled
+		if err := tx.Save(&o).Error; err != nil {
+			return err
+		}
+		if err := resolveOrderOutcome(tx, o.ID, models.OutcomeCanceled); err != nil {
+			return err
+		}
+		return releasePromotions(tx, o.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// DeliverOrder marks an assigned order delivered, frees its vehicle, settles the driver payout with the
+// wait the driver reported and issues the invoice. The payout is settled on the pricing the order was placed at, and the
+// wait line is priced with the config version and variant recorded on it, so neither re-pricings nor
+// later config changes alter the pay. driverID is the user delivering it, who must drive the assigned
+// vehicle; 0 lets an admin deliver any order.
+func DeliverOrder(db *gorm.DB, id, driverID uint, waitMinutes float64, cfg *config.Config) (*models.Order, *models.PricingHistory, error) {
+	if !(waitMinutes >= 0 && waitMinutes <= MaxWaitMinutes) {
+		return nil, nil, ErrWaitTooLong
+	}
+	var o models.Order
+	var ph models.PricingHistory
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&o, id).Error; err != nil {
+			return err
+		}
+		if o.Status != config.OrderStatusAssigned || o.AssignedToID == nil {
+			return ErrOrderNotAssigned
+		}
+		var v models.Vehicle
+		if err := tx.First(&v, *o.AssignedToID).Error; err != nil {
+			return err
+		}
+		if driverID != 0 && v.DriverID != driverID {
+			return ErrNotOrderDriver
+		}
+		if err := tx.Model(&v).Update("status", "available").Error; err != nil {
+			return err
+		}
+		if err := orderPricing(tx, o.ID, &ph); err != nil {
+			return err
+		}
+		pcfg, err := recordedPricing(tx, &ph, cfg)
+		if err != nil {
+			return err
+		}
+		payouts, err := pricing.NewPayoutModel(pcfg)
+		if err != nil {
+			return err
+		}
+		var payout pricing.Payout
+		for _, c := range ph.Components {
+			if c.Side == models.SidePayout {
+				payout.Lines = append(payout.Lines, pricing.Line{Component: c.Name, Amount: c.Amount})
+			}
+		}
+		setPayout(&ph, payouts.WithWait(payout, waitMinutes))
+		ph.WaitMinutes = waitMinutes
+		if err := tx.Where("pricing_history_id = ? AND side <> ?", ph.ID, models.SideFee).Delete(&models.PricingComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Components").Save(&ph).Error; err != nil {
+			return err
+		}
+		for i := range ph.Components {
+			if ph.Components[i].Side != models.SideFee {
+				ph.Components[i].ID = 0
+				ph.Components[i].PricingHistoryID = ph.ID
+				if err := tx.Create(&ph.Components[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		o.Status = config.OrderStatusDelivered
+		o.WaitMinutes = waitMinutes
+		o.DriverPayout = ph.Payout
+		if err := tx.Save(&o).Error; err != nil {
+			return err
+		}
+		return issueInvoice(tx, &o, cfg, time.Now())
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &o, &ph, nil
+}
+
+// OrderReconciliation returns the pricing an order was placed at with its fee, payout and margin lines,
+// the payout as settled on delivery.
+func OrderReconciliation(db *gorm.DB, orderID uint) (*models.PricingHistory, error) {
+	var ph models.PricingHistory
+	if err := orderPricing(db, orderID, &ph); err != nil {
+		return nil, err
+	}
+	return &ph, nil
+}
+
 func GetOrderStatus(db *gorm.DB, id uint) (string, error) {
 	var o models.Order
 	if err := db.First(&o, id).Error; err != nil {
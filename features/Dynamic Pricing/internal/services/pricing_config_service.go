@@ -0,0 +1,218 @@
+package services
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/pricing"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrConfigVersionNotFound = errors.New("pricing config version not found")
+	ErrInvalidPricingConfig  = errors.New("invalid pricing config")
+)
+
+// RecordPricingVersion validates v, stores it as the next version and makes it live.
+func RecordPricingVersion(db *gorm.DB, v *config.PricingVersion, author, source, comment string, cfg *config.Config) (*models.PricingConfigVersion, error) {
+	if err := pricing.Validate(v, cfg.Subscriptions.Plans); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPricingConfig, err)
+	}
+	if v.Pricing.Currency != cfg.Pricing.Currency {
+		return nil, fmt.Errorf("%w: currency cannot change without a restart", ErrInvalidPricingConfig)
+	}
+	doc, sum, err := v.Document()
+	if err != nil {
+		return nil, err
+	}
+	row := &models.PricingConfigVersion{Author: author, Source: source, Comment: comment, Document: doc, Checksum: sum}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&models.PricingConfigVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		row.Version = last + 1
+		return tx.Create(row).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	activate(v, row.Version, cfg)
+	return row, nil
+}
+
+// RollbackPricingVersion stores the document of an earlier version as a new version and makes it live.
+func RollbackPricingVersion(db *gorm.DB, version int, author string, cfg *config.Config) (*models.PricingConfigVersion, error) {
+	old, err := GetPricingVersion(db, version)
+	if err != nil {
+		return nil, err
+	}
+	v, err := config.ParsePricingVersion([]byte(old.Document))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPricingConfig, err)
+	}
+	row, err := RecordPricingVersion(db, v, author, models.ConfigSourceRollback, fmt.Sprintf("rollback to version %d", version), cfg)
+	if err != nil {
+		return nil, err
+	}
+	row.RolledBackFrom = version
+	return row, db.Model(row).Update("rolled_back_from", version).Error
+}
+
+// ListPricingVersions returns every stored version, newest first, without documents.
+func ListPricingVersions(db *gorm.DB) ([]models.PricingConfigVersion, error) {
+	var out []models.PricingConfigVersion
+	err := db.Omit("document").Order("version DESC").Find(&out).Error
+	return out, err
+}
+
+func GetPricingVersion(db *gorm.DB, version int) (*models.PricingConfigVersion, error) {
+	var row models.PricingConfigVersion
+	if err := db.Where("version = ?", version).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConfigVersionNotFound
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// DiffPricingVersions lists the keys that differ from version from to version to.
+func DiffPricingVersions(db *gorm.DB, from, to int) ([]config.Change, error) {
+	a, err := GetPricingVersion(db, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := GetPricingVersion(db, to)
+	if err != nil {
+		return nil, err
+	}
+	return config.DiffDocuments(a.Document, b.Document)
+}
+
+// LatestPricingVersion parses the highest stored version. It returns nil when none is stored.
+func LatestPricingVersion(db *gorm.DB) (*config.PricingVersion, error) {
+	var rows []models.PricingConfigVersion
+	if err := db.Order("version DESC").Limit(1).Find(&rows).Error; err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	row := rows[0]
+	v, err := config.ParsePricingVersion([]byte(row.Document))
+	if err != nil {
+		return nil, fmt.Errorf("pricing config version %d: %w", row.Version, err)
+	}
+	v.Version = row.Version
+	return v, nil
+}
+
+// recordedPricing is the pricing config ph was computed with: the experiment variant, or else the base
+// pricing, of its config version. Version 0 is the config file.
+func recordedPricing(db *gorm.DB, ph *models.PricingHistory, cfg *config.Config) (config.PricingCfg, error) {
+	v := cfg.Live()
+	if ph.ConfigVersion == 0 {
+		v = &config.PricingVersion{Pricing: cfg.Pricing, Experiments: cfg.Experiments}
+	} else if ph.ConfigVersion != v.Version {
+		row, err := GetPricingVersion(db, ph.ConfigVersion)
+		if err != nil {
+			return config.PricingCfg{}, err
+		}
+		if v, err = config.ParsePricingVersion([]byte(row.Document)); err != nil {
+			return config.PricingCfg{}, fmt.Errorf("pricing config version %d: %w", row.Version, err)
+		}
+	}
+	for _, e := range v.Experiments {
+		if ph.Experiment == "" || e.Name != ph.Experiment {
+			continue
+		}
+		for _, vr := range e.Variants {
+			if vr.Name == ph.Variant {
+				return vr.Resolved, nil
+			}
+		}
+	}
+	return v.Pricing, nil
+}
+
+func activate(v *config.PricingVersion, version int, cfg *config.Config) {
+	v.Version = version
+	cfg.Activate(v)
+	log.Printf("pricing config version %d live", version)
+}
+
+// PricingConfigWatcher keeps the live pricing version in step with the config file and the database.
+// A changed file is stored as a new version; a newer stored version (from an admin or another
+// instance) is activated.
+type PricingConfigWatcher struct {
+	db      *gorm.DB
+	cfg     *config.Config
+	fileSum [sha256.Size]byte
+}
+
+func NewPricingConfigWatcher(db *gorm.DB, cfg *config.Config) *PricingConfigWatcher {
+	return &PricingConfigWatcher{db: db, cfg: cfg}
+}
+
+// Run syncs every cfg.Reload.Interval, forever.
+func (w *PricingConfigWatcher) Run() {
+	for range time.Tick(w.cfg.Reload.Interval) {
+		if err := w.Sync(); err != nil {
+			log.Printf("pricing config reload: %v", err)
+		}
+	}
+}
+
+// Sync records the config file if its pricing differs from the last version recorded from the file,
+// then activates the latest stored version if it is not live yet.
+func (w *PricingConfigWatcher) Sync() error {
+	if err := w.syncFile(); err != nil {
+		return err
+	}
+	latest, err := LatestPricingVersion(w.db)
+	if err != nil || latest == nil {
+		return err
+	}
+	if latest.Version == w.cfg.Live().Version {
+		return nil
+	}
+	if err := pricing.Validate(latest, w.cfg.Subscriptions.Plans); err != nil {
+		return fmt.Errorf("version %d: %w", latest.Version, err)
+	}
+	activate(latest, latest.Version, w.cfg)
+	return nil
+}
+
+func (w *PricingConfigWatcher) syncFile() error {
+	raw, err := os.ReadFile(config.Path())
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	if sum == w.fileSum {
+		return nil
+	}
+	w.fileSum = sum // an invalid file is reported once, not on every tick
+	v, err := config.ParsePricingVersion(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", config.Path(), err)
+	}
+	_, docSum, err := v.Document()
+	if err != nil {
+		return err
+	}
+	var last []models.PricingConfigVersion
+	err = w.db.Omit("document").Where("source = ?", models.ConfigSourceFile).Order("version DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return err
+	}
+	if len(last) > 0 && last[0].Checksum == docSum {
+		return nil
+	}
+	_, err = RecordPricingVersion(w.db, v, config.Path(), models.ConfigSourceFile, "", w.cfg)
+	return err
+}
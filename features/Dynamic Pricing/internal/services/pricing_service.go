@@ -1,67 +1,250 @@
 package services
 
 import (
-	"fmt"
+	"log"
 	"math"
+	"strings"
+	"time"
 
 	"VOID/config"
 	"VOID/internal/cache"
+	"VOID/internal/geo"
 	"VOID/internal/models"
+	"VOID/internal/money"
 	"VOID/internal/pricing"
+	"VOID/internal/surge"
 	"gorm.io/gorm"
 )
 
-func CalculateDynamicPrice(db *gorm.DB, orderID uint, cacheClient *cache.Cache, cfg *config.Config) (float64, map[string]float64) {
+var weatherProvider pricing.WeatherProvider = pricing.NoWeather{}
+
+// SetWeatherProvider sets the feed priceOrder reads the weather condition at the pickup from.
+func SetWeatherProvider(p pricing.WeatherProvider) {
+	weatherProvider = p
+}
+
+// CalculateDynamicPrice re-prices an existing order at the current inputs and records the result as a
+// re-pricing; the price the order was placed at is left as it is.
+func CalculateDynamicPrice(db *gorm.DB, orderID uint, cacheClient *cache.Cache, cfg *config.Config) (*pricing.Breakdown, error) {
 	var o models.Order
 	if err := db.First(&o, orderID).Error; err != nil {
-		return 0, map[string]float64{"error": 1}
+		return nil, err
+	}
+	p, err := priceOrder(db, &o, cacheClient, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if o.PromoCode != "" {
+		applyOrderPromotion(db, o.ID, p)
+	}
+	ph := newPricingHistory(p)
+	ph.Outcome = models.OutcomeRepriced
+	_ = db.Create(ph).Error
+	return &p.Breakdown, nil
+}
+
+// OrderPricing returns the price an order was placed at, as stored at checkout or when its quote was redeemed.
+func OrderPricing(db *gorm.DB, orderID uint) (*pricing.Breakdown, error) {
+	var ph models.PricingHistory
+	if err := orderPricing(db, orderID, &ph); err != nil {
+		return nil, err
+	}
+	b := storedBreakdown(&ph)
+	return &b, nil
+}
+
+// orderPricing loads the pricing row an order was placed at, with its lines: the checkout row or the
+// redeemed quote, never a later re-pricing.
+func orderPricing(db *gorm.DB, orderID uint, ph *models.PricingHistory) error {
+	return db.Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Order("side, position") }).
+		Where("order_id = ? AND COALESCE(outcome, '') <> ?", orderID, models.OutcomeRepriced).Order("id").First(ph).Error
+}
+
+// storedBreakdown rebuilds the customer breakdown recorded on ph.
+func storedBreakdown(ph *models.PricingHistory) pricing.Breakdown {
+	b := pricing.Breakdown{
+		Subtotal:      ph.Subtotal,
+		Discount:      money.New(0, ph.FinalPrice.Currency),
+		Total:         ph.FinalPrice,
+		Floored:       ph.Floored,
+		Capped:        ph.Capped,
+		Plan:          ph.Plan,
+		MemberSavings: ph.MemberSavings,
+	}
+	if ph.Rules != "" {
+		b.Rules = strings.Split(ph.Rules, ",")
+	}
+	for _, c := range ph.Components {
+		if c.Side != models.SideFee {
+			continue
+		}
+		b.Lines = append(b.Lines, pricing.Line{Component: c.Name, Amount: c.Amount})
+		if c.Name == pricing.ComponentCartTier || c.Name == pricing.ComponentPromo {
+			b.Discount = b.Discount.Sub(c.Amount)
+		}
+	}
+	return b
+}
+
+// pricedOrder is everything a pricing call produced, as recorded in PricingHistory.
+type pricedOrder struct {
+	Request    pricing.PricingRequest
+	Assignment pricing.Assignment
+	Breakdown  pricing.Breakdown
+	Payout     pricing.Payout
+	PromoCode  string // set once a promotion discount is applied
+}
+
+// priceOrder runs o through the pipeline of the user's experiment variant, with the member pricing of their
+// subscription plan on top, and computes the driver payout of the same request. o does not need to be persisted.
+func priceOrder(db *gorm.DB, o *models.Order, cacheClient *cache.Cache, cfg *config.Config) (*pricedOrder, error) {
+	assignment := pricing.Assign(cfg, o.UserID)
+	pipeline, err := pricing.NewPipeline(assignment.Pricing)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := pricing.NewPayoutModel(assignment.Pricing)
+	if err != nil {
+		return nil, err
 	}
 	dist := o.DistanceKm
 	if dist == 0 {
-		dist = approxDistanceKm(o.PickupLat, o.PickupLon, o.DropoffLat, o.DropoffLon)
-	}
-	trafficScore := 0.3
-	if cacheClient != nil && cacheClient.RedisClient != nil {
-		if v, err := cacheClient.Get(fmt.Sprintf("traffic:%d", orderID)); err == nil && v != "" {
-			var parsed float64
-			fmt.Sscanf(v, "%f", &parsed)
-			trafficScore = parsed
+		dist = roadDistanceKm(o.PickupLat, o.PickupLon, o.DropoffLat, o.DropoffLon, cfg)
+	}
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	trafficScore := RouteTrafficScore(db, grid.LineZones(o.PickupLat, o.PickupLon, o.DropoffLat, o.DropoffLon), cacheClient, cfg)
+	req := pickupFactorsAt(db, o.PickupLat, o.PickupLon, cacheClient, cfg, time.Now()).request(o, dist, trafficScore)
+	req.TripMinutes = payouts.TripMinutes(req)
+	b := pipeline.Price(req)
+	// Drivers are paid off the regular price, whatever the customer's plan.
+	payout := payouts.Payout(req, b)
+	plan, err := memberPlan(db, o.UserID, cfg, req.At)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		member, err := plan.MemberPricing(assignment.Pricing)
+		if err != nil {
+			return nil, err
+		}
+		memberPipeline, err := pricing.NewPipeline(member)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		facts := pricing.LoadFactors()
-		trafficScore = facts.TrafficDensity
-	}
-	demandIndex := computeDemandIndex(db)
-	pcfg := cfg.Pricing
-	final := pricing.CalculatePrice(pcfg.BasePrice, dist, trafficScore, demandIndex, pcfg)
-	ph := models.PricingHistory{
-		OrderID:      orderID,
-		BasePrice:    pcfg.BasePrice,
-		DistanceKm:   dist,
-		TrafficScore: trafficScore,
-		DemandIndex:  demandIndex,
-		FinalPrice:   final,
-	}
-	_ = db.Create(&ph).Error
-	return math.Round(final*100) / 100, map[string]float64{
-		"base":     pcfg.BasePrice,
-		"distance": dist,
-		"traffic":  trafficScore,
-		"demand":   demandIndex,
-		"final":    final,
+		mb := memberPipeline.Price(req)
+		mb.Member(plan.Name, b)
+		b = mb
+	}
+	return &pricedOrder{Request: req, Assignment: assignment, Breakdown: b, Payout: payout}, nil
+}
+
+// pickupFactors are the pricing inputs that depend only on the pickup point and the time.
+type pickupFactors struct {
+	zone            string
+	demandIndex     float64
+	forecastDemand  float64
+	surgeMultiplier float64
+	surgeCap        float64
+	weather         string
+	at              time.Time
+}
+
+func pickupFactorsAt(db *gorm.DB, lat, lon float64, cacheClient *cache.Cache, cfg *config.Config, now time.Time) pickupFactors {
+	zone, demandIndex := ZoneDemandIndex(db, cfg, lat, lon)
+	surgeMultiplier, _ := surge.Multiplier(cacheClient, zone)
+	weather, err := weatherProvider.Conditions(lat, lon, now)
+	if err != nil {
+		log.Printf("weather lookup: %v", err)
+	}
+	return pickupFactors{
+		zone:            zone,
+		demandIndex:     demandIndex,
+		forecastDemand:  forecastDemand(db, zone, now),
+		surgeMultiplier: surgeMultiplier,
+		surgeCap:        surgeCap(db, cfg, zone, now),
+		weather:         weather,
+		at:              now,
+	}
+}
+
+// request is the pricing request of o from the pickup of f, over dist km of road with trafficScore.
+func (f pickupFactors) request(o *models.Order, dist, trafficScore float64) pricing.PricingRequest {
+	return pricing.PricingRequest{
+		OrderID:         o.ID,
+		UserID:          o.UserID,
+		Zone:            f.zone,
+		DistanceKm:      dist,
+		TrafficScore:    trafficScore,
+		DemandIndex:     f.demandIndex,
+		ForecastDemand:  f.forecastDemand,
+		SurgeMultiplier: f.surgeMultiplier,
+		SurgeCap:        f.surgeCap,
+		Priority:        o.Priority,
+		Weather:         f.weather,
+		CartValue:       o.CartSubtotal,
+		WaitMinutes:     o.WaitMinutes,
+		At:              f.at,
 	}
 }
 
+func newPricingHistory(p *pricedOrder) *models.PricingHistory {
+	req, b := p.Request, p.Breakdown
+	ph := &models.PricingHistory{
+		OrderID:         req.OrderID,
+		Zone:            req.Zone,
+		UserID:          req.UserID,
+		ConfigVersion:   p.Assignment.Version,
+		Experiment:      p.Assignment.Experiment,
+		Variant:         p.Assignment.Variant,
+		BasePrice:       b.Amount(pricing.ComponentBase),
+		DistanceKm:      req.DistanceKm,
+		TrafficScore:    req.TrafficScore,
+		DemandIndex:     req.DemandIndex,
+		ForecastDemand:  req.ForecastDemand,
+		SurgeMultiplier: req.SurgeMultiplier,
+		PriceMultiplier: pricing.SurgeMultiplier(p.Assignment.Pricing, req),
+		SurgeCap:        req.SurgeCap,
+		Priority:        req.Priority,
+		Weather:         req.Weather,
+		CartValue:       req.CartValue,
+		TripMinutes:     req.TripMinutes,
+		WaitMinutes:     req.WaitMinutes,
+		Subtotal:        b.Subtotal,
+		Floored:         b.Floored,
+		Capped:          b.Capped,
+		Rules:           strings.Join(b.Rules, ","),
+		Plan:            b.Plan,
+		MemberSavings:   b.MemberSavings,
+		PromoCode:       p.PromoCode,
+		Discount:        b.Amount(pricing.ComponentPromo).Neg(),
+		FinalPrice:      b.Total,
+		CreatedAt:       req.At,
+	}
+	for i, l := range b.Lines {
+		ph.Components = append(ph.Components, models.PricingComponent{Side: models.SideFee, Position: i, Name: l.Component, Amount: l.Amount})
+	}
+	setPayout(ph, p.Payout)
+	return ph
+}
+
+// setPayout records payout on ph, replacing any payout and margin lines, and recomputes the margin.
+func setPayout(ph *models.PricingHistory, payout pricing.Payout) {
+	lines := ph.Components[:0]
+	for _, c := range ph.Components {
+		if c.Side == models.SideFee {
+			lines = append(lines, c)
+		}
+	}
+	for i, l := range payout.Lines {
+		lines = append(lines, models.PricingComponent{Side: models.SidePayout, Position: i, Name: l.Component, Amount: l.Amount})
+	}
+	ph.Payout, ph.PayoutFloored = payout.Total, payout.Floored
+	ph.PlatformMargin = ph.FinalPrice.Sub(payout.Total)
+	ph.Components = append(lines, models.PricingComponent{Side: models.SideMargin, Name: models.SideMargin, Amount: ph.PlatformMargin})
+}
+
 func approxDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
 	dx := lat1 - lat2
 	dy := lon1 - lon2
 	return math.Sqrt(dx*dx+dy*dy) * 111
 }
-
-func computeDemandIndex(db *gorm.DB) float64 {
-	var pending int64
-	var avail int64
-	_ = db.Model(&models.Order{}).Where("status = ?", config.OrderStatusPending).Count(&pending).Error
-	_ = db.Model(&models.Vehicle{}).Where("status = ?", "available").Count(&avail).Error
-	return float64(pending) / (float64(avail) + 1.0)
-}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPromoNotFound    = errors.New("unknown promo code")
+	ErrPromoInactive    = errors.New("promo code is not active")
+	ErrPromoNotEligible = errors.New("order is not eligible for this promo code")
+	ErrPromoExhausted   = errors.New("promo code usage limit reached")
+)
+
+func CreatePromotion(db *gorm.DB, p *models.Promotion) error {
+	p.Code = normalizePromoCode(p.Code)
+	if p.Code == "" {
+		return errors.New("code required")
+	}
+	switch p.Type {
+	case models.PromoTypeFlat, models.PromoTypePercent:
+		if p.Value <= 0 {
+			return errors.New("value must be positive")
+		}
+	case models.PromoTypeFreeDelivery:
+	default:
+		return errors.New("type must be flat, percent or free_delivery")
+	}
+	return db.Create(p).Error
+}
+
+func ListPromotions(db *gorm.DB) ([]models.Promotion, error) {
+	var out []models.Promotion
+	err := db.Order("id").Find(&out).Error
+	return out, err
+}
+
+// reservePromotion checks that code applies to the priced order, takes the discount off its breakdown and
+// reserves one use of the promotion. It must run inside tx: the promotion row is written first so that
+// concurrent reservations of the same code serialise before the usage limits are counted.
+// A quote reservation (orderID 0) only counts against the limits until expiresAt.
+func reservePromotion(tx *gorm.DB, code string, p *pricedOrder, orderID uint, quoteID string, expiresAt *time.Time) error {
+	var promo models.Promotion
+	if err := tx.Where("code = ?", normalizePromoCode(code)).First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPromoNotFound
+		}
+		return err
+	}
+	now := time.Now()
+	if err := checkPromotion(&promo, p.Request, now); err != nil {
+		return err
+	}
+	if err := tx.Model(&promo).Update("updated_at", now).Error; err != nil {
+		return err
+	}
+	active := func() *gorm.DB {
+		return tx.Model(&models.PromotionRedemption{}).
+			Where("promotion_id = ? AND status = ?", promo.ID, models.RedemptionReserved).
+			Where("order_id <> 0 OR expires_at > ?", now)
+	}
+	if promo.MaxRedemptions > 0 {
+		var n int64
+		if err := active().Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= int64(promo.MaxRedemptions) {
+			return ErrPromoExhausted
+		}
+	}
+	if promo.PerUserLimit > 0 {
+		var n int64
+		if err := active().Where("user_id = ?", p.Request.UserID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= int64(promo.PerUserLimit) {
+			return ErrPromoExhausted
+		}
+	}
+	discount := p.Breakdown.ApplyDiscount(pricing.ComponentPromo, promotionDiscount(&promo, p.Breakdown.Total))
+	p.PromoCode = promo.Code
+	return tx.Create(&models.PromotionRedemption{
+		PromotionID: promo.ID,
+		UserID:      p.Request.UserID,
+		OrderID:     orderID,
+		QuoteID:     quoteID,
+		Status:      models.RedemptionReserved,
+		Discount:    discount,
+		ExpiresAt:   expiresAt,
+	}).Error
+}
+
+// applyOrderPromotion re-applies the promotion an order already holds when it is re-priced.
+func applyOrderPromotion(db *gorm.DB, orderID uint, p *pricedOrder) {
+	var r models.PromotionRedemption
+	if err := db.Where("order_id = ? AND status = ?", orderID, models.RedemptionReserved).First(&r).Error; err != nil {
+		return
+	}
+	var promo models.Promotion
+	if err := db.First(&promo, r.PromotionID).Error; err != nil {
+		return
+	}
+	p.Breakdown.ApplyDiscount(pricing.ComponentPromo, promotionDiscount(&promo, p.Breakdown.Total))
+	p.PromoCode = promo.Code
+}
+
+// claimQuotePromotion moves a quote's reservation onto the order created from it.
+func claimQuotePromotion(tx *gorm.DB, quoteID string, orderID uint) error {
+	return tx.Model(&models.PromotionRedemption{}).
+		Where("quote_id = ? AND status = ?", quoteID, models.RedemptionReserved).
+		Updates(map[string]interface{}{"order_id": orderID, "expires_at": nil}).Error
+}
+
+// releasePromotions frees the promotion uses held by a canceled order.
+func releasePromotions(tx *gorm.DB, orderID uint) error {
+	return tx.Model(&models.PromotionRedemption{}).
+		Where("order_id = ? AND status = ?", orderID, models.RedemptionReserved).
+		Update("status", models.RedemptionReleased).Error
+}
+
+func checkPromotion(p *models.Promotion, req pricing.PricingRequest, now time.Time) error {
+	if (!p.ValidFrom.IsZero() && now.Before(p.ValidFrom)) || (!p.ValidTo.IsZero() && !now.Before(p.ValidTo)) {
+		return ErrPromoInactive
+	}
+	if p.PerUserLimit > 0 && req.UserID == 0 {
+		return ErrPromoNotEligible
+	}
+	if p.MinCartValue > 0 && req.CartValue.Float64() < p.MinCartValue {
+		return ErrPromoNotEligible
+	}
+	if p.Zones != "" && !inList(p.Zones, req.Zone) {
+		return ErrPromoNotEligible
+	}
+	if p.Priorities != "" && !inList(p.Priorities, req.Priority) {
+		return ErrPromoNotEligible
+	}
+	return nil
+}
+
+// promotionDiscount is the discount p gives on total. Promotion values are in major units and round half up.
+func promotionDiscount(p *models.Promotion, total money.Amount) money.Amount {
+	switch p.Type {
+	case models.PromoTypeFlat:
+		return money.FromFloat(p.Value, total.Currency, money.HalfUp)
+	case models.PromoTypePercent:
+		d := total.Percent(p.Value, money.HalfUp)
+		if p.MaxDiscount > 0 {
+			d = money.Min(d, money.FromFloat(p.MaxDiscount, total.Currency, money.HalfUp))
+		}
+		return d
+	case models.PromoTypeFreeDelivery:
+		return total
+	}
+	return money.New(0, total.Currency)
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func inList(csv, v string) bool {
+	for _, s := range strings.Split(csv, ",") {
+		if strings.TrimSpace(s) == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuoteExpired  = errors.New("quote expired")
+	ErrQuoteInvalid  = errors.New("invalid quote")
+	ErrQuoteMismatch = errors.New("order does not match quote")
+	ErrQuoteUsed     = errors.New("quote already used")
+)
+
+// QuoteClaims is the signed payload of a quote token. The token itself is the quote ID handed to clients.
+type QuoteClaims struct {
+	UserID       uint              `json:"uid,omitempty"`
+	PickupLat    float64           `json:"plat"`
+	PickupLon    float64           `json:"plon"`
+	DropoffLat   float64           `json:"dlat"`
+	DropoffLon   float64           `json:"dlon"`
+	Priority     string            `json:"pri"`
+	Category     string            `json:"cat,omitempty"`
+	CartSubtotal money.Amount      `json:"cart"`
+	PromoCode    string            `json:"promo,omitempty"`
+	Breakdown    pricing.Breakdown `json:"bd"`
+	Type         string            `json:"typ"` // always tokenTypeQuote
+	jwt.RegisteredClaims
+}
+
+// CreateQuote prices o without persisting it and returns a signed quote token valid for cfg.Quotes.TTL.
+// A promo code is reserved for the quote until it expires.
+func CreateQuote(db *gorm.DB, o *models.Order, promoCode string, cacheClient *cache.Cache, cfg *config.Config) (string, *QuoteClaims, error) {
+	jti, err := newQuoteID()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(cfg.Quotes.TTL)
+	var p *pricedOrder
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = priceOrder(tx, o, cacheClient, cfg); err != nil {
+			return err
+		}
+		if promoCode != "" {
+			if err := reservePromotion(tx, promoCode, p, 0, jti, &expiresAt); err != nil {
+				return err
+			}
+		}
+		ph := newPricingHistory(p)
+		ph.QuoteID = jti
+		ph.Outcome, ph.ExpiresAt = models.OutcomePending, &expiresAt
+		return tx.Create(ph).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	claims := &QuoteClaims{
+		UserID:       o.UserID,
+		PickupLat:    o.PickupLat,
+		PickupLon:    o.PickupLon,
+		DropoffLat:   o.DropoffLat,
+		DropoffLon:   o.DropoffLon,
+		Priority:     o.Priority,
+		Category:     o.Category,
+		CartSubtotal: o.CartSubtotal,
+		PromoCode:    p.PromoCode,
+		Breakdown:    p.Breakdown,
+		Type:         tokenTypeQuote,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(quoteKey(cfg))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// VerifyQuote checks the signature, type and expiry of a quote token.
+func VerifyQuote(token string, cfg *config.Config) (*QuoteClaims, error) {
+	claims := &QuoteClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return quoteKey(cfg), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrQuoteExpired
+	}
+	if err != nil || claims.Type != tokenTypeQuote {
+		return nil, ErrQuoteInvalid
+	}
+	return claims, nil
+}
+
+// ApplyQuote copies the quoted trip and locked price onto o. The quote must have been priced for o's user,
+// and fields already set on o must agree with it.
+func ApplyQuote(o *models.Order, q *QuoteClaims) error {
+	if o.UserID != q.UserID ||
+		(o.Priority != "" && o.Priority != q.Priority) ||
+		(o.Category != "" && o.Category != q.Category) ||
+		(o.PickupLat != 0 && o.PickupLat != q.PickupLat) ||
+		(o.PickupLon != 0 && o.PickupLon != q.PickupLon) ||
+		(o.DropoffLat != 0 && o.DropoffLat != q.DropoffLat) ||
+		(o.DropoffLon != 0 && o.DropoffLon != q.DropoffLon) ||
+		(!o.CartSubtotal.IsZero() && o.CartSubtotal != q.CartSubtotal) {
+		return ErrQuoteMismatch
+	}
+	o.PickupLat, o.PickupLon = q.PickupLat, q.PickupLon
+	o.DropoffLat, o.DropoffLon = q.DropoffLat, q.DropoffLon
+	o.Priority = q.Priority
+	o.Category = q.Category
+	o.CartSubtotal = q.CartSubtotal
+	o.PromoCode = q.PromoCode
+	o.ComputedPrice = q.Breakdown.Total
+	return nil
+}
+
+// CreateOrderFromQuote persists o (already filled by ApplyQuote) and claims the quote for it, so each quote
+// can be redeemed only once. Tax is computed on the quoted breakdown.
+func CreateOrderFromQuote(db *gorm.DB, o *models.Order, q *QuoteClaims, cfg *config.Config) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := CreateOrder(tx, o); err != nil {
+			return err
+		}
+		res := tx.Model(&models.PricingHistory{}).
+			Where("quote_id = ? AND order_id = 0", q.ID).
+			Updates(map[string]interface{}{"order_id": o.ID, "outcome": models.OutcomeConverted, "outcome_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrQuoteUsed
+		}
+		var ph models.PricingHistory
+		if err := tx.Select("payout_minor", "payout_currency").Where("quote_id = ?", q.ID).First(&ph).Error; err != nil {
+			return err
+		}
+		o.DriverPayout = ph.Payout
+		if err := applyOrderTax(tx, o, q.Breakdown, cfg); err != nil {
+			return err
+		}
+		if err := tx.Model(o).Updates(models.Order{DriverPayout: o.DriverPayout, Tax: o.Tax}).Error; err != nil {
+			return err
+		}
+		return claimQuotePromotion(tx, q.ID, o.ID)
+	})
+}
+
+// quoteKey is the key quote tokens are signed with: HMAC(secret, "quote") of the login secret, so a quote
+// token, which anyone can obtain, never verifies as a login token or the other way round.
+func quoteKey(cfg *config.Config) []byte {
+	secret := jwtKey
+	if cfg != nil && cfg.JWT.Secret != "" {
+		secret = []byte(cfg.JWT.Secret)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(tokenTypeQuote))
+	return mac.Sum(nil)
+}
+
+func newQuoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,88 +1,255 @@
 package services
 
 import (
-	"container/heap"
-	"math"
+	"errors"
+	"strconv"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/geo"
+	"VOID/internal/routing"
+	"VOID/internal/traffic"
+	"gorm.io/gorm"
 )
 
-// Very small in-memory sample graph (demo). Replace with OSM/OSRM or PostGIS-based routing in prod.
-type edge struct {
-	to     string
-	weight float64
-}
-
-var sampleGraph = map[string][]edge{
-	"A": {{"B", 1}, {"C", 4}},
-	"B": {{"A", 1}, {"C", 2}, {"D", 5}},
-	"C": {{"A", 4}, {"B", 2}, {"D", 1}},
-	"D": {{"B", 5}, {"C", 1}},
-}
-
-func ComputeOptimalRoute(start, end string) ([]string, float64) {
-	dist := map[string]float64{}
-	prev := map[string]string{}
-	for k := range sampleGraph {
-		dist[k] = math.Inf(1)
-	}
-	dist[start] = 0
-	pq := &PriorityQueue{}
-	heap.Init(pq)
-	heap.Push(pq, &Item{value: start, priority: 0})
-	for pq.Len() > 0 {
-		u := heap.Pop(pq).(*Item).value
-		if u == end {
-			break
-		}
-		for _, e := range sampleGraph[u] {
-			if dist[u]+e.weight < dist[e.to] {
-				dist[e.to] = dist[u] + e.weight
-				prev[e.to] = u
-				heap.Push(pq, &Item{value: e.to, priority: dist[e.to]})
-			}
-		}
+var (
+	ErrNoRoadGraph = errors.New("routing is not configured")
+	ErrNoHierarchy = errors.New("no contraction hierarchy is loaded")
+	ErrOffRoad     = errors.New("point is too far from any road")
+	ErrNoRoute     = errors.New("no route between the points")
+)
+
+var (
+	roadGraph     *routing.Graph
+	roadHierarchy *routing.CH
+)
+
+// SetRoadGraph sets the road graph ComputeOptimalRoute routes on.
+func SetRoadGraph(g *routing.Graph) {
+	roadGraph = g
+}
+
+// SetRoadHierarchy sets the road graph to that of h and answers "ch" routes and road distances with h.
+func SetRoadHierarchy(h *routing.CH) {
+	roadGraph, roadHierarchy = h.Graph, h
+}
+
+type LatLon struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Snap is the road node a route end was moved to.
+type Snap struct {
+	OSMID  int64   `json:"osm_id"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Meters float64 `json:"distance_m"` // from the requested point
+}
+
+// Route is a road route between two points.
+type Route struct {
+	Path     []LatLon  `json:"path"`
+	Meters   float64   `json:"distance_m"`
+	Seconds  float64   `json:"duration_s"`  // with congestion
+	FreeFlow float64   `json:"free_flow_s"` // without congestion
+	DepartAt time.Time `json:"depart_at"`
+	ArriveAt time.Time `json:"arrive_at"`
+	From     Snap      `json:"from"`
+	To       Snap      `json:"to"`
+
+	Algorithm string  `json:"algorithm"`
+	Expanded  int     `json:"expanded"`  // nodes the search settled
+	SearchMs  float64 `json:"search_ms"` // time spent in the search alone
+}
+
+// ComputeOptimalRoute snaps both points to the nearest road node and finds the fastest route between
+// them leaving at depart (zero for now). An edge with congestion c takes (1+c) times its free-flow time,
+// so a standstill doubles it; see congestion for where c comes from. algorithm names the search;
+// empty uses the configured one. "ch" answers from the contraction hierarchy: with a bidirectional query
+// while no fresh reading or traffic profile slows any edge, else with A* guided by the free-flow times
+// the hierarchy gives (routing.CHAStar), which routes around the congestion. Route.Algorithm names the
+// search that ran.
+func ComputeOptimalRoute(db *gorm.DB, fromLat, fromLon, toLat, toLon float64, algorithm string, depart time.Time, cfg *config.Config) (*Route, error) {
+	g := roadGraph
+	if g == nil {
+		return nil, ErrNoRoadGraph
 	}
-	path := []string{}
-	u := end
-	if _, ok := prev[u]; !ok && u != start {
-		return []string{}, math.Inf(1)
+	now := time.Now()
+	if depart.IsZero() {
+		depart = now
 	}
-	for {
-		path = append([]string{u}, path...)
-		if u == start {
-			break
-		}
-		u = prev[u]
+	weights, err := newCongestion(db, g, []LatLon{{Lat: fromLat, Lon: fromLon}, {Lat: toLat, Lon: toLon}}, now, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == "" {
+		algorithm = cfg.Routing.Algorithm
+	}
+	search, err := searcher(algorithm, g, weights)
+	if err != nil {
+		return nil, err
+	}
+	from, err := snap(g, fromLat, fromLon, cfg)
+	if err != nil {
+		return nil, err
+	}
+	to, err := snap(g, toLat, toLon, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := search.ShortestPath(from, to, weights.weight(depart))
+	if !ok {
+		return nil, ErrNoRoute
+	}
+	r := &Route{
+		Meters: p.Meters, Seconds: p.Cost, FreeFlow: p.Seconds,
+		DepartAt: depart, ArriveAt: depart.Add(time.Duration(p.Cost * float64(time.Second))),
+		From: snapOf(g, from, fromLat, fromLon), To: snapOf(g, to, toLat, toLon),
+		Algorithm: search.Name(), Expanded: p.Expanded, SearchMs: float64(p.Elapsed.Microseconds()) / 1000,
+	}
+	for _, n := range p.Nodes {
+		r.Path = append(r.Path, LatLon{Lat: g.Nodes[n].Lat, Lon: g.Nodes[n].Lon})
+	}
+	return r, nil
+}
+
+func searcher(algorithm string, g *routing.Graph, weights *congestion) (routing.Searcher, error) {
+	if algorithm != "ch" {
+		return routing.NewSearcher(algorithm, g)
 	}
-	return path, dist[end]
+	if roadHierarchy == nil {
+		return nil, ErrNoHierarchy
+	}
+	if weights.freeFlow() {
+		return roadHierarchy, nil
+	}
+	return routing.CHAStar{H: roadHierarchy}, nil
+}
+
+// roadDistanceKm is the length of the fastest free-flow road route between two points when a contraction
+// hierarchy is loaded and both points are near a road, else the straight-line estimate.
+func roadDistanceKm(fromLat, fromLon, toLat, toLon float64, cfg *config.Config) float64 {
+	h := roadHierarchy
+	if h == nil {
+		return approxDistanceKm(fromLat, fromLon, toLat, toLon)
+	}
+	from, err1 := snap(h.Graph, fromLat, fromLon, cfg)
+	to, err2 := snap(h.Graph, toLat, toLon, cfg)
+	if err1 != nil || err2 != nil {
+		return approxDistanceKm(fromLat, fromLon, toLat, toLon)
+	}
+	p, ok := h.ShortestPath(from, to, routing.FreeFlow)
+	if !ok {
+		return approxDistanceKm(fromLat, fromLon, toLat, toLon)
+	}
+	return p.Meters / 1000
 }
 
-type Item struct {
-	value    string
-	priority float64
-	index    int
+func snap(g *routing.Graph, lat, lon float64, cfg *config.Config) (int32, error) {
+	n, m := g.Nearest(lat, lon)
+	if n < 0 || m > cfg.Routing.MaxSnapMeters {
+		return 0, ErrOffRoad
+	}
+	return n, nil
+}
+
+func snapOf(g *routing.Graph, n int32, lat, lon float64) Snap {
+	node := g.Nodes[n]
+	return Snap{OSMID: node.OSMID, Lat: node.Lat, Lon: node.Lon, Meters: geo.DistanceMeters(lat, lon, node.Lat, node.Lon)}
+}
+
+// congestion weighs the edges of a graph by free-flow time times (1 + congestion) at the time the edge is
+// entered. Up to cfg.Traffic.TTL from now the fresh reading of the edge, or else of the zone it starts in,
+// applies; otherwise, or without a reading, the hour-of-week profile of the edge or else of the zone;
+// otherwise free flow. Edge readings and profiles are keyed by traffic.EdgeID of the OSM node IDs, and
+// profiles are read in the pricing time zone they were fitted in.
+type congestion struct {
+	g            *routing.Graph
+	zones        map[string]float64
+	byEdge       map[[2]int32]float64
+	profiles     *traffic.Profiles
+	edgeProfiles map[[2]int32]*traffic.Profile
+	loc          *time.Location
+	grid         geo.Grid
+	nodeZone     map[int32]string
+	// Readings stay fresh for TTL after they were observed, so none is used beyond liveUntil.
+	liveUntil time.Time
+}
+
+// newCongestion reads the fresh readings around points (see FreshCongestion) and the current traffic profiles.
+func newCongestion(db *gorm.DB, g *routing.Graph, points []LatLon, now time.Time, cfg *config.Config) (*congestion, error) {
+	zones, edges, err := FreshCongestion(db, points, cfg, now)
+	if err != nil {
+		return nil, err
+	}
+	profiles := trafficProfiles.Load()
+	if profiles == nil {
+		profiles = traffic.NewProfiles()
+	}
+	return &congestion{
+		g: g, zones: zones, byEdge: nodePairs(g, edges), profiles: profiles, edgeProfiles: nodePairs(g, profiles.Edges),
+		loc: cfg.Live().Pricing.Location(), grid: geo.Grid{CellSize: cfg.Zones.CellSizeDeg}, nodeZone: map[int32]string{},
+		liveUntil: now.Add(cfg.Traffic.TTL),
+	}, nil
 }
-type PriorityQueue []*Item
 
-func (pq PriorityQueue) Len() int { return len(pq) }
-func (pq PriorityQueue) Less(i, j int) bool {
-	return pq[i].priority < pq[j].priority
+// freeFlow reports whether every edge takes its free-flow time at any departure.
+func (c *congestion) freeFlow() bool {
+	return len(c.zones) == 0 && len(c.byEdge) == 0 && len(c.profiles.Zones) == 0 && len(c.edgeProfiles) == 0
 }
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
+
+func (c *congestion) zoneOf(n int32) string {
+	z, ok := c.nodeZone[n]
+	if !ok {
+		z = c.grid.ZoneID(c.g.Nodes[n].Lat, c.g.Nodes[n].Lon)
+		c.nodeZone[n] = z
+	}
+	return z
 }
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := len(*pq)
-	item := x.(*Item)
-	item.index = n
-	*pq = append(*pq, item)
+
+// weight is the routing.WeightFunc of a departure at depart.
+func (c *congestion) weight(depart time.Time) routing.WeightFunc {
+	live := c.liveUntil.Sub(depart).Seconds()
+	return func(from int32, e *routing.Edge, elapsed float64) float64 {
+		pair := [2]int32{from, e.To}
+		if elapsed < live {
+			if v, ok := c.byEdge[pair]; ok {
+				return e.Seconds * (1 + v)
+			}
+			if v, ok := c.zones[c.zoneOf(from)]; ok {
+				return e.Seconds * (1 + v)
+			}
+		}
+		p, ok := c.edgeProfiles[pair]
+		if !ok {
+			if p, ok = c.profiles.Zones[c.zoneOf(from)]; !ok {
+				return e.Seconds
+			}
+		}
+		return e.Seconds * (1 + p.At(depart.Add(time.Duration(elapsed*float64(time.Second))), c.loc))
+	}
 }
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	item := old[n-1]
-	item.index = -1
-	*pq = old[0 : n-1]
-	return item
+
+// nodePairs re-keys values by traffic.EdgeID of OSM node IDs to the graph nodes they join, dropping IDs
+// naming nodes the graph does not have.
+func nodePairs[V any](g *routing.Graph, byID map[string]V) map[[2]int32]V {
+	out := map[[2]int32]V{}
+	for id, v := range byID {
+		a, b, ok := traffic.SplitEdgeID(id)
+		if !ok {
+			continue
+		}
+		fromID, err1 := strconv.ParseInt(a, 10, 64)
+		toID, err2 := strconv.ParseInt(b, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		from, ok1 := g.Node(fromID)
+		to, ok2 := g.Node(toID)
+		if ok1 && ok2 {
+			out[[2]int32{from, to}] = v
+		}
+	}
+	return out
 }
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownPlan       = errors.New("unknown subscription plan")
+	ErrAlreadySubscribed = errors.New("user already has an active subscription")
+	ErrNoSubscription    = errors.New("no active subscription")
+)
+
+// Subscribe starts a pass on plan for userID. Payment is taken elsewhere; Price records what the period costs.
+func Subscribe(db *gorm.DB, userID uint, plan string, autoRenew bool, cfg *config.Config) (*models.Subscription, error) {
+	p := cfg.Plan(plan)
+	if p == nil {
+		return nil, ErrUnknownPlan
+	}
+	var s *models.Subscription
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if _, err := ActiveSubscription(tx, userID, cfg, now); err == nil {
+			return ErrAlreadySubscribed
+		} else if !errors.Is(err, ErrNoSubscription) {
+			return err
+		}
+		s = &models.Subscription{
+			UserID:    userID,
+			Plan:      p.Name,
+			Price:     money.FromFloat(p.Price, currency(cfg), money.HalfUp),
+			StartsAt:  now,
+			EndsAt:    now.Add(p.Period),
+			AutoRenew: autoRenew,
+		}
+		return tx.Create(s).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ActiveSubscription returns the pass giving userID member pricing at now, including one in its grace period.
+func ActiveSubscription(db *gorm.DB, userID uint, cfg *config.Config, now time.Time) (*models.Subscription, error) {
+	var subs []models.Subscription
+	err := db.Where("user_id = ? AND starts_at <= ? AND ends_at > ?", userID, now, now.Add(-cfg.Subscriptions.GracePeriod)).
+		Order("ends_at DESC").Find(&subs).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if subs[i].ActiveAt(now, cfg.Subscriptions.GracePeriod) && cfg.Plan(subs[i].Plan) != nil {
+			return &subs[i], nil
+		}
+	}
+	return nil, ErrNoSubscription
+}
+
+// RenewSubscription extends the user's pass by one period. A pass renewed during its grace period keeps
+// its original end date as the start of the new period.
+func RenewSubscription(db *gorm.DB, userID uint, cfg *config.Config) (*models.Subscription, error) {
+	var s *models.Subscription
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if s, err = ActiveSubscription(tx, userID, cfg, time.Now()); err != nil {
+			return err
+		}
+		s.EndsAt = s.EndsAt.Add(cfg.Plan(s.Plan).Period)
+		return tx.Model(s).Update("ends_at", s.EndsAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CancelSubscription turns off auto-renew. The pass keeps member pricing until it ends, without a grace period.
+func CancelSubscription(db *gorm.DB, userID uint, cfg *config.Config) (*models.Subscription, error) {
+	var s *models.Subscription
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		now := time.Now()
+		if s, err = ActiveSubscription(tx, userID, cfg, now); err != nil {
+			return err
+		}
+		if !s.AutoRenew {
+			return nil
+		}
+		s.AutoRenew, s.CanceledAt = false, &now
+		return tx.Model(s).Updates(map[string]interface{}{"auto_renew": false, "canceled_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// memberPlan returns the plan userID is priced under, or nil for non-members and anonymous users.
+func memberPlan(db *gorm.DB, userID uint, cfg *config.Config, now time.Time) (*config.PlanCfg, error) {
+	if userID == 0 || len(cfg.Subscriptions.Plans) == 0 {
+		return nil, nil
+	}
+	s, err := ActiveSubscription(db, userID, cfg, now)
+	if errors.Is(err, ErrNoSubscription) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Plan(s.Plan), nil
+}
+
+func currency(cfg *config.Config) string {
+	if cfg.Pricing.Currency == "" {
+		return money.DefaultCurrency
+	}
+	return cfg.Pricing.Currency
+}
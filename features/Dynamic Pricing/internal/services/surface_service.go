@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+	"gorm.io/gorm"
+)
+
+// MaxSurfaceCells bounds the grid of one price surface.
+const MaxSurfaceCells = 2500
+
+// MinSurfaceResolution is the finest cell size of a price surface, in degrees (about 11 m).
+const MinSurfaceResolution = 1e-4
+
+var ErrSurfaceTooLarge = errors.New("price surface exceeds the cell limit")
+
+// Surface asks what a delivery from Origin would cost to each cell of a grid of CellSize degrees over
+// the box between the south-west and north-east corners.
+type Surface struct {
+	OriginLat, OriginLon float64
+	MinLat, MinLon       float64
+	MaxLat, MaxLon       float64
+	CellSize             float64
+	Priority             string
+}
+
+// PriceSurface prices a delivery from the origin to the center of every cell with the live base pricing
+// (no experiment variant or member plan) and the current demand, surge, weather and traffic. Nothing is
+// persisted.
+func PriceSurface(db *gorm.DB, s Surface, cacheClient *cache.Cache, cfg *config.Config, now time.Time) (*geo.FeatureCollection, error) {
+	// The epsilon keeps float error from adding a sliver row or column when the box is a whole number of cells.
+	fRows := math.Ceil((s.MaxLat-s.MinLat)/s.CellSize - 1e-9)
+	fCols := math.Ceil((s.MaxLon-s.MinLon)/s.CellSize - 1e-9)
+	// Each side is bounded before converting and multiplying, which could otherwise overflow; the negated
+	// comparisons also reject NaN.
+	if !(fRows >= 1 && fRows <= MaxSurfaceCells) || !(fCols >= 1 && fCols <= MaxSurfaceCells) {
+		return nil, ErrSurfaceTooLarge
+	}
+	rows, cols := int(fRows), int(fCols)
+	if rows*cols > MaxSurfaceCells {
+		return nil, ErrSurfaceTooLarge
+	}
+	live := cfg.Live()
+	pipeline, err := pricing.NewPipeline(live.Pricing)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := pricing.NewPayoutModel(live.Pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	routes := make([][]string, rows*cols)
+	var crossed []string
+	seen := map[string]bool{}
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			lat, lon := s.cellCenter(r, c)
+			route := zones.LineZones(s.OriginLat, s.OriginLon, lat, lon)
+			routes[r*cols+c] = route
+			for _, z := range route {
+				if !seen[z] {
+					seen[z] = true
+					crossed = append(crossed, z)
+				}
+			}
+		}
+	}
+	latest := freshestCongestion(db, crossed, nil, cacheClient, cfg)
+	pickup := pickupFactorsAt(db, s.OriginLat, s.OriginLon, cacheClient, cfg, now)
+
+	fc := geo.NewFeatureCollection()
+	o := &models.Order{Priority: s.Priority}
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			lat, lon := s.cellCenter(r, c)
+			dist := roadDistanceKm(s.OriginLat, s.OriginLon, lat, lon, cfg)
+			req := pickup.request(o, dist, routeScore(latest, routes[r*cols+c], cfg))
+			req.TripMinutes = payouts.TripMinutes(req)
+			b := pipeline.Price(req)
+			components := make(map[string]money.Amount, len(b.Lines))
+			for _, l := range b.Lines {
+				components[l.Component] = l.Amount
+			}
+			minLat, minLon := s.MinLat+float64(r)*s.CellSize, s.MinLon+float64(c)*s.CellSize
+			fc.Features = append(fc.Features, geo.NewFeature(
+				geo.Rect(minLat, minLon, math.Min(minLat+s.CellSize, s.MaxLat), math.Min(minLon+s.CellSize, s.MaxLon)),
+				map[string]interface{}{
+					"price":         b.Total,
+					"components":    components,
+					"rules":         b.Rules,
+					"eta_minutes":   math.Round(req.TripMinutes*10) / 10,
+					"distance_km":   math.Round(dist*100) / 100,
+					"traffic_score": req.TrafficScore,
+				}))
+		}
+	}
+	fc.Features = append(fc.Features, geo.NewFeature(geo.Point(s.OriginLat, s.OriginLon), map[string]interface{}{"origin": true}))
+	fc.Properties = map[string]interface{}{
+		"priced_at":        now,
+		"config_version":   live.Version,
+		"zone":             pickup.zone,
+		"demand_index":     pickup.demandIndex,
+		"surge_multiplier": pricing.SurgeMultiplier(live.Pricing, pickup.request(o, 0, 0)),
+		"weather":          pickup.weather,
+		"cell_size":        s.CellSize,
+		"rows":             rows,
+		"cols":             cols,
+	}
+	return fc, nil
+}
+
+// cellCenter is the center of cell (r, c), clipped to the box for the last row and column.
+func (s Surface) cellCenter(r, c int) (lat, lon float64) {
+	minLat, minLon := s.MinLat+float64(r)*s.CellSize, s.MinLon+float64(c)*s.CellSize
+	return (minLat + math.Min(minLat+s.CellSize, s.MaxLat)) / 2, (minLon + math.Min(minLon+s.CellSize, s.MaxLon)) / 2
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/routing"
+	"VOID/internal/tour"
+	"gorm.io/gorm"
+)
+
+// MaxTourJobs bounds the jobs of one tour; the road matrix grows with the square of the stops.
+const MaxTourJobs = 25
+
+var ErrTourTooLarge = fmt.Errorf("a tour takes at most %d jobs", MaxTourJobs)
+
+// TourJob is one delivery of a tour: the pickup and dropoff of OrderID when it is set, else the given points.
+type TourJob struct {
+	OrderID uint
+	Pickup  LatLon
+	Dropoff LatLon
+}
+
+// TourStop is a stop of a planned tour with the leg that reaches it.
+type TourStop struct {
+	Job      int       `json:"job"` // index into the requested jobs
+	OrderID  uint      `json:"order_id,omitempty"`
+	Kind     string    `json:"kind"` // pickup or dropoff
+	Snap     Snap      `json:"snap"`
+	Meters   float64   `json:"leg_distance_m"`
+	Seconds  float64   `json:"leg_duration_s"`
+	ETA      float64   `json:"eta_s"` // since departure
+	ArriveAt time.Time `json:"arrive_at"`
+}
+
+// Tour is the planned visiting order of a batch of jobs.
+type Tour struct {
+	Start    Snap       `json:"start"`
+	Stops    []TourStop `json:"stops"`
+	Meters   float64    `json:"distance_m"`
+	Seconds  float64    `json:"duration_s"`
+	DepartAt time.Time  `json:"depart_at"`
+	ArriveAt time.Time  `json:"arrive_at"` // at the last stop
+	Path     []LatLon   `json:"path"`
+}
+
+// PlanTour orders the pickups and dropoffs of jobs for a rider leaving start at depart (zero for now),
+// each pickup before its dropoff, to finish the last stop soonest. Stops are ordered on the road travel
+// times between them leaving at depart, with the weights of ComputeOptimalRoute; the chosen legs are then
+// timed one after another, each leaving when the previous one arrives.
+func PlanTour(db *gorm.DB, start LatLon, jobs []TourJob, depart time.Time, cfg *config.Config) (*Tour, error) {
+	g := roadGraph
+	if g == nil {
+		return nil, ErrNoRoadGraph
+	}
+	if len(jobs) > MaxTourJobs {
+		return nil, ErrTourTooLarge
+	}
+	now := time.Now()
+	if depart.IsZero() {
+		depart = now
+	}
+	points := []LatLon{start}
+	for i := range jobs {
+		j := &jobs[i]
+		if j.OrderID != 0 {
+			var o models.Order
+			if err := db.First(&o, j.OrderID).Error; err != nil {
+				return nil, err
+			}
+			j.Pickup, j.Dropoff = LatLon{Lat: o.PickupLat, Lon: o.PickupLon}, LatLon{Lat: o.DropoffLat, Lon: o.DropoffLon}
+		}
+		points = append(points, j.Pickup, j.Dropoff)
+	}
+	nodes := make([]int32, len(points))
+	for i, p := range points {
+		n, err := snap(g, p.Lat, p.Lon, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, stopName(i))
+		}
+		nodes[i] = n
+	}
+
+	weights, err := newCongestion(db, g, points, now, cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := weights.weight(depart)
+	cost := make([][]float64, len(nodes))
+	for i, from := range nodes {
+		paths, ok := routing.OneToMany(g, from, nodes, w)
+		cost[i] = make([]float64, len(nodes))
+		for j := range nodes {
+			if !ok[j] {
+				return nil, fmt.Errorf("%w: from %s to %s", ErrNoRoute, stopName(i), stopName(j))
+			}
+			cost[i][j] = paths[j].Cost
+		}
+	}
+
+	t := &Tour{Start: snapOf(g, nodes[0], start.Lat, start.Lon), DepartAt: depart, ArriveAt: depart}
+	search := routing.AStar{G: g}
+	prev := 0
+	var path []int32
+	for _, s := range tour.Plan(cost) {
+		p, ok := search.ShortestPath(nodes[prev], nodes[s], weights.weight(t.ArriveAt))
+		if !ok {
+			return nil, ErrNoRoute
+		}
+		t.Meters += p.Meters
+		t.Seconds += p.Cost
+		t.ArriveAt = depart.Add(time.Duration(t.Seconds * float64(time.Second)))
+		job := (s - 1) / 2
+		stop := TourStop{
+			Job: job, OrderID: jobs[job].OrderID, Kind: "pickup",
+			Snap:   snapOf(g, nodes[s], points[s].Lat, points[s].Lon),
+			Meters: p.Meters, Seconds: p.Cost, ETA: t.Seconds, ArriveAt: t.ArriveAt,
+		}
+		if s%2 == 0 {
+			stop.Kind = "dropoff"
+		}
+		t.Stops = append(t.Stops, stop)
+		if len(path) > 0 {
+			p.Nodes = p.Nodes[1:]
+		}
+		path = append(path, p.Nodes...)
+		prev = s
+	}
+	for _, n := range path {
+		t.Path = append(t.Path, LatLon{Lat: g.Nodes[n].Lat, Lon: g.Nodes[n].Lon})
+	}
+	return t, nil
+}
+
+// stopName names point i of a tour: the start, then the pickup and dropoff of each job.
+func stopName(i int) string {
+	switch {
+	case i == 0:
+		return "start"
+	case i%2 == 1:
+		return fmt.Sprintf("pickup of job %d", (i-1)/2)
+	}
+	return fmt.Sprintf("dropoff of job %d", (i-1)/2)
+}
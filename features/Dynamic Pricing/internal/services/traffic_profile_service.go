@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/models"
+	"VOID/internal/traffic"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoTrafficProfile = errors.New("no traffic profile")
+
+var (
+	// trafficProfiles are the profiles routes are timed with: the learned ones overlaid with fileProfiles.
+	trafficProfiles atomic.Pointer[traffic.Profiles]
+	fileProfiles    *traffic.Profiles
+)
+
+// LoadTrafficProfiles reads cfg.Traffic.Profiles.File, if set, and the stored learned profiles, and
+// starts timing routes with them.
+func LoadTrafficProfiles(db *gorm.DB, cfg *config.Config) error {
+	if cfg.Traffic.Profiles.File != "" {
+		p, err := traffic.ReadProfiles(cfg.Traffic.Profiles.File)
+		if err != nil {
+			return err
+		}
+		fileProfiles = p
+	}
+	return reloadTrafficProfiles(db)
+}
+
+func reloadTrafficProfiles(db *gorm.DB) error {
+	var rows []models.TrafficProfile
+	if err := db.Find(&rows).Error; err != nil {
+		return err
+	}
+	set := traffic.NewProfiles()
+	for _, r := range rows {
+		if len(r.Congestion) != traffic.WeekHours {
+			continue
+		}
+		var p traffic.Profile
+		copy(p[:], r.Congestion)
+		if r.Zone != "" {
+			set.Zones[r.Zone] = &p
+		} else {
+			set.Edges[r.Edge] = &p
+		}
+	}
+	if fileProfiles != nil {
+		for id, p := range fileProfiles.Zones {
+			set.Zones[id] = p
+		}
+		for id, p := range fileProfiles.Edges {
+			set.Edges[id] = p
+		}
+	}
+	trafficProfiles.Store(set)
+	return nil
+}
+
+// FitTrafficProfiles learns the hour-of-week profile of every zone and edge with at least
+// cfg.Traffic.Profiles.MinReadings readings in the last cfg.Traffic.Profiles.History, stores them and
+// starts timing routes with them.
+func FitTrafficProfiles(db *gorm.DB, cfg *config.Config, now time.Time) ([]models.TrafficProfile, error) {
+	pc := cfg.Traffic.Profiles
+	var readings []models.TrafficReading
+	err := db.Select("zone", "edge", "congestion", "observed_at").
+		Where("observed_at > ? AND observed_at <= ?", now.Add(-pc.History), now).Find(&readings).Error
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ zone, edge string }
+	samples := map[key]*traffic.Samples{}
+	loc := cfg.Live().Pricing.Location()
+	for _, r := range readings {
+		k := key{r.Zone, r.Edge}
+		s := samples[k]
+		if s == nil {
+			s = &traffic.Samples{}
+			samples[k] = s
+		}
+		s.Add(traffic.HourOfWeek(r.ObservedAt, loc), r.Congestion)
+	}
+	var fitted []models.TrafficProfile
+	for k, s := range samples {
+		if n := s.Count(); n >= pc.MinReadings {
+			p := s.Profile()
+			fitted = append(fitted, models.TrafficProfile{Zone: k.zone, Edge: k.edge, Congestion: p[:], Readings: n, FittedAt: now})
+		}
+	}
+	if len(fitted) > 0 {
+		err = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "zone"}, {Name: "edge"}},
+			DoUpdates: clause.AssignmentColumns([]string{"congestion", "readings", "fitted_at"}),
+		}).Create(&fitted).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fitted, reloadTrafficProfiles(db)
+}
+
+// RunTrafficProfileFits refits the traffic profiles every cfg.Traffic.Profiles.Interval, starting immediately.
+func RunTrafficProfileFits(db *gorm.DB, cfg *config.Config) {
+	for {
+		if fitted, err := FitTrafficProfiles(db, cfg, time.Now()); err != nil {
+			log.Printf("traffic profile refit: %v", err)
+		} else {
+			log.Printf("traffic profile refit: %d zones and edges", len(fitted))
+		}
+		time.Sleep(cfg.Traffic.Profiles.Interval)
+	}
+}
+
+// TrafficProfile returns the profile routes use for a zone or, when zone is empty, an edge.
+func TrafficProfile(zone, edge string) (*traffic.Profile, error) {
+	set := trafficProfiles.Load()
+	if set != nil {
+		p := set.Zones[zone]
+		if zone == "" {
+			p = set.Edges[edge]
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+	return nil, ErrNoTrafficProfile
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+	"VOID/internal/geo"
+	"VOID/internal/models"
+	"VOID/internal/traffic"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidReading = errors.New("invalid traffic reading")
+
+// maxClockSkew is how far in the future a reading's timestamp may be before it is rejected.
+const maxClockSkew = time.Minute
+
+// IngestTraffic validates readings, appends them to the history table and publishes each to the cache
+// for the rest of its cfg.Traffic.TTL. A reading without a timestamp is taken as observed now. Zone IDs
+// are rewritten in the form geo.Grid.ID gives, and one that is not the corner of a grid cell is rejected.
+func IngestTraffic(db *gorm.DB, readings []models.TrafficReading, cacheClient *cache.Cache, cfg *config.Config) error {
+	now := time.Now()
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	for i := range readings {
+		r := &readings[i]
+		if (r.Zone == "") == (r.Edge == "") {
+			return fmt.Errorf("%w: reading %d needs exactly one of zone or edge", ErrInvalidReading, i)
+		}
+		if r.Zone != "" {
+			c, ok := grid.ParseID(r.Zone)
+			if !ok {
+				return fmt.Errorf("%w: reading %d zone %q is not a grid zone ID; give lat and lon instead", ErrInvalidReading, i, r.Zone)
+			}
+			r.Zone = grid.ID(c)
+			r.CellLat, r.CellLon = grid.Corner(c)
+		} else {
+			r.CellLat, r.CellLon = edgeCell(grid, r.Edge)
+		}
+		if r.Congestion < 0 || r.Congestion > 1 {
+			return fmt.Errorf("%w: reading %d congestion must be within 0..1", ErrInvalidReading, i)
+		}
+		if r.ObservedAt.IsZero() {
+			r.ObservedAt = now
+		}
+		if r.ObservedAt.After(now.Add(maxClockSkew)) {
+			return fmt.Errorf("%w: reading %d is in the future", ErrInvalidReading, i)
+		}
+	}
+	if len(readings) == 0 {
+		return nil
+	}
+	if err := db.Create(&readings).Error; err != nil {
+		return err
+	}
+	for _, r := range readings {
+		if err := traffic.Publish(cacheClient, readingKey(r.Zone, r.Edge), r.Congestion, r.ObservedAt, cfg.Traffic.TTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RouteTrafficScore is the mean congestion over the zones of a route that have a fresh reading, or
+// cfg.Traffic.DefaultScore when none do.
+func RouteTrafficScore(db *gorm.DB, zones []string, cacheClient *cache.Cache, cfg *config.Config) float64 {
+	return routeScore(freshestCongestion(db, zones, nil, cacheClient, cfg), zones, cfg)
+}
+
+// routeScore is the mean of the readings in latest over zones, or cfg.Traffic.DefaultScore when none has one.
+func routeScore(latest map[string]float64, zones []string, cfg *config.Config) float64 {
+	sum, n := 0.0, 0
+	for _, z := range zones {
+		if v, ok := latest[z]; ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return cfg.Traffic.DefaultScore
+	}
+	return sum / float64(n)
+}
+
+// freshestCongestion reads the freshest reading per zone or edge from the cache, falling back to the
+// history table for ones the cache does not hold (an in-process cache is empty after a restart).
+// The result is keyed by zone or edge ID.
+func freshestCongestion(db *gorm.DB, zones, edges []string, cacheClient *cache.Cache, cfg *config.Config) map[string]float64 {
+	out := map[string]float64{}
+	var missZones, missEdges []string
+	for _, z := range zones {
+		if v, _, ok := traffic.Latest(cacheClient, traffic.ZoneKey(z)); ok {
+			out[z] = v
+		} else {
+			missZones = append(missZones, z)
+		}
+	}
+	for _, e := range edges {
+		if v, _, ok := traffic.Latest(cacheClient, traffic.EdgeKey(e)); ok {
+			out[e] = v
+		} else {
+			missEdges = append(missEdges, e)
+		}
+	}
+	if db == nil || len(missZones)+len(missEdges) == 0 {
+		return out
+	}
+	q := db.Where("observed_at > ?", time.Now().Add(-cfg.Traffic.TTL))
+	switch {
+	case len(missZones) > 0 && len(missEdges) > 0:
+		q = q.Where(db.Where("zone IN ?", missZones).Or("edge IN ?", missEdges))
+	case len(missZones) > 0:
+		q = q.Where("zone IN ?", missZones)
+	default:
+		q = q.Where("edge IN ?", missEdges)
+	}
+	var rows []models.TrafficReading
+	if err := q.Order("observed_at DESC").Find(&rows).Error; err != nil {
+		return out
+	}
+	for _, r := range rows {
+		id := r.Zone
+		if id == "" {
+			id = r.Edge
+		}
+		if _, ok := out[id]; !ok {
+			out[id] = r.Congestion
+			_ = traffic.Publish(cacheClient, readingKey(r.Zone, r.Edge), r.Congestion, r.ObservedAt, cfg.Traffic.TTL)
+		}
+	}
+	return out
+}
+
+// edgeCell is the corner of the grid zone of the first node of an edge, or 0, 0 when the road graph does
+// not have the node; routing never reads a reading of such an edge.
+func edgeCell(grid geo.Grid, edge string) (lat, lon float64) {
+	g := roadGraph
+	a, _, ok := traffic.SplitEdgeID(edge)
+	if g == nil || !ok {
+		return 0, 0
+	}
+	id, err := strconv.ParseInt(a, 10, 64)
+	if err != nil {
+		return 0, 0
+	}
+	n, ok := g.Node(id)
+	if !ok {
+		return 0, 0
+	}
+	return grid.Corner(grid.CellOf(g.Nodes[n].Lat, g.Nodes[n].Lon))
+}
+
+// routeMargin is how many grid zones beyond the box of its points a route may stray, at the least; a
+// route spanning more zones may stray a quarter of its span.
+const routeMargin = 4
+
+// FreshCongestion returns the readings observed in the last cfg.Traffic.TTL in the grid zones around
+// points, the newest per zone and per edge. A route search may wander off the box the points span, so the
+// box is grown by routeMargin zones or a quarter of its size, whichever is more; readings further out are
+// not read, and congestion there is left to the profiles.
+func FreshCongestion(db *gorm.DB, points []LatLon, cfg *config.Config, now time.Time) (zones, edges map[string]float64, err error) {
+	zones, edges = map[string]float64{}, map[string]float64{}
+	if len(points) == 0 {
+		return zones, edges, nil
+	}
+	grid := geo.Grid{CellSize: cfg.Zones.CellSizeDeg}
+	lo := grid.CellOf(points[0].Lat, points[0].Lon)
+	hi := lo
+	for _, p := range points[1:] {
+		c := grid.CellOf(p.Lat, p.Lon)
+		lo.Row, lo.Col = min(lo.Row, c.Row), min(lo.Col, c.Col)
+		hi.Row, hi.Col = max(hi.Row, c.Row), max(hi.Col, c.Col)
+	}
+	margin := max(routeMargin, (hi.Row-lo.Row)/4, (hi.Col-lo.Col)/4)
+	minLat, minLon := grid.Corner(geo.Cell{Row: lo.Row - margin, Col: lo.Col - margin})
+	maxLat, maxLon := grid.Corner(geo.Cell{Row: hi.Row + margin + 1, Col: hi.Col + margin + 1})
+
+	var rows []models.TrafficReading
+	err = db.Select("zone", "edge", "congestion").Where("observed_at > ?", now.Add(-cfg.Traffic.TTL)).
+		Where("cell_lat >= ? AND cell_lat < ? AND cell_lon >= ? AND cell_lon < ?", minLat, maxLat, minLon, maxLon).
+		Order("observed_at DESC").Find(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, r := range rows {
+		if r.Zone != "" {
+			if _, ok := zones[r.Zone]; !ok {
+				zones[r.Zone] = r.Congestion
+			}
+		} else if _, ok := edges[r.Edge]; !ok {
+			edges[r.Edge] = r.Congestion
+		}
+	}
+	return zones, edges, nil
+}
+
+func readingKey(zone, edge string) string {
+	if zone != "" {
+		return traffic.ZoneKey(zone)
+	}
+	return traffic.EdgeKey(edge)
+}
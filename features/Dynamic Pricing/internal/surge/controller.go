@@ -0,0 +1,133 @@
+package surge
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"VOID/config"
+	"VOID/internal/cache"
+)
+
+// Key is the cache key the controller publishes a zone's multiplier under.
+func Key(zone string) string {
+	return "surge:" + zone
+}
+
+// Multiplier reads the published multiplier of zone. ok is false when none is published.
+func Multiplier(c *cache.Cache, zone string) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	v, err := c.Get(Key(zone))
+	if err != nil || v == "" {
+		return 0, false
+	}
+	m, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false
+	}
+	return m, true
+}
+
+// DemandSource returns the current raw demand index per zone; zones left out count as 0.
+type DemandSource func() map[string]float64
+
+type zoneState struct {
+	ewma       float64
+	surging    bool
+	multiplier float64
+}
+
+// Controller keeps an exponentially weighted demand signal per zone and publishes a rate-limited,
+// hysteretic surge multiplier for each to the cache.
+type Controller struct {
+	cfg    config.SurgeCfg
+	conf   *config.Config
+	cache  *cache.Cache
+	demand DemandSource
+
+	mu    sync.Mutex
+	zones map[string]*zoneState
+	last  time.Time
+}
+
+func NewController(cfg *config.Config, c *cache.Cache, demand DemandSource) *Controller {
+	return &Controller{
+		cfg:    cfg.Surge,
+		conf:   cfg,
+		cache:  c,
+		demand: demand,
+		zones:  map[string]*zoneState{},
+	}
+}
+
+// Run ticks every cfg.Interval, forever.
+func (sc *Controller) Run() {
+	t := time.NewTicker(sc.cfg.Interval)
+	defer t.Stop()
+	sc.Tick(time.Now())
+	for now := range t.C {
+		sc.Tick(now)
+	}
+}
+
+// Tick samples demand once, updates every zone and publishes the multipliers.
+func (sc *Controller) Tick(now time.Time) {
+	samples := sc.demand()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	elapsed := sc.cfg.Interval
+	if !sc.last.IsZero() {
+		elapsed = now.Sub(sc.last)
+	}
+	sc.last = now
+	maxStep := sc.cfg.MaxStepPerMinute * elapsed.Minutes() // 0 -> unlimited
+
+	for zone := range samples {
+		if _, ok := sc.zones[zone]; !ok {
+			sc.zones[zone] = &zoneState{multiplier: 1}
+		}
+	}
+	for zone, st := range sc.zones {
+		sample := samples[zone]
+		st.ewma = sc.cfg.Alpha*sample + (1-sc.cfg.Alpha)*st.ewma
+
+		if !st.surging && st.ewma >= sc.cfg.EnterThreshold {
+			st.surging = true
+		} else if st.surging && st.ewma < sc.cfg.ExitThreshold {
+			st.surging = false
+		}
+		target := 1.0
+		if st.surging {
+			target = sc.targetMultiplier(st.ewma)
+		}
+		st.multiplier = step(st.multiplier, target, maxStep)
+
+		if !st.surging && st.multiplier == 1 && st.ewma < 0.01 {
+			delete(sc.zones, zone)
+		}
+		_ = sc.cache.Set(Key(zone), strconv.FormatFloat(st.multiplier, 'f', 4, 64), sc.cfg.TTL)
+	}
+}
+
+// targetMultiplier maps a demand level to a multiplier the same way the surge component does.
+func (sc *Controller) targetMultiplier(demand float64) float64 {
+	p := sc.conf.Live().Pricing
+	if p.PeakDemand <= 0 || p.MaxSurgeMultiplier <= 1 {
+		return 1
+	}
+	return 1 + (p.MaxSurgeMultiplier-1)*math.Min(demand/p.PeakDemand, 1)
+}
+
+func step(cur, target, maxStep float64) float64 {
+	if maxStep <= 0 {
+		return target
+	}
+	if d := target - cur; math.Abs(d) > maxStep {
+		return cur + math.Copysign(maxStep, d)
+	}
+	return target
+}
@@ -0,0 +1,96 @@
+// Package tax applies the configured GST-style tax rules to the fee lines of a price.
+package tax
+
+import (
+	"VOID/config"
+	"VOID/internal/money"
+	"VOID/internal/pricing"
+)
+
+// Line is one tax on one fee line.
+type Line struct {
+	Component string       `json:"component"`
+	Tax       string       `json:"tax"`
+	Rate      float64      `json:"rate"` // percent
+	Taxable   money.Amount `json:"taxable"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// Rules taxes fee lines per cfg.
+type Rules struct {
+	cfg  config.TaxCfg
+	mode money.RoundingMode
+}
+
+func NewRules(cfg config.TaxCfg, mode money.RoundingMode) *Rules {
+	return &Rules{cfg: cfg, mode: mode}
+}
+
+// Inclusive reports whether fee lines already include their tax.
+func (r *Rules) Inclusive() bool { return r.cfg.Inclusive }
+
+// Apply taxes each non-zero fee line of b with the first rule matching the line's component and category.
+// With inclusive pricing the tax is carved out of the line, so taxable value plus taxes equal the line.
+func (r *Rules) Apply(b pricing.Breakdown, category string) []Line {
+	var out []Line
+	for _, l := range b.Lines {
+		if l.Amount.IsZero() {
+			continue
+		}
+		rule := r.match(l.Component, category)
+		if rule == nil || len(rule.Taxes) == 0 {
+			continue
+		}
+		taxable, amounts := l.Amount, make([]money.Amount, len(rule.Taxes))
+		for i, t := range rule.Taxes {
+			if r.cfg.Inclusive {
+				amounts[i] = l.Amount.MulFloat(t.Rate/(100+totalRate(rule)), r.mode)
+				taxable = taxable.Sub(amounts[i])
+			} else {
+				amounts[i] = l.Amount.Percent(t.Rate, r.mode)
+			}
+		}
+		for i, t := range rule.Taxes {
+			out = append(out, Line{Component: l.Component, Tax: t.Name, Rate: t.Rate, Taxable: taxable, Amount: amounts[i]})
+		}
+	}
+	return out
+}
+
+// Total sums lines in currency.
+func Total(lines []Line, currency string) money.Amount {
+	total := money.New(0, currency)
+	for _, l := range lines {
+		total = total.Add(l.Amount)
+	}
+	return total
+}
+
+func (r *Rules) match(component, category string) *config.TaxRuleCfg {
+	for i, rule := range r.cfg.Rules {
+		if matches(rule.Components, component) && matches(rule.Categories, category) {
+			return &r.cfg.Rules[i]
+		}
+	}
+	return nil
+}
+
+func matches(set []string, v string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	for _, s := range set {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func totalRate(rule *config.TaxRuleCfg) float64 {
+	var total float64
+	for _, t := range rule.Taxes {
+		total += t.Rate
+	}
+	return total
+}
@@ -0,0 +1,150 @@
+// Package tour orders the stops of a trip that picks up and drops off several jobs.
+package tour
+
+import "math"
+
+// maxPasses bounds the improvement passes of Plan; each pass applies every improving move it finds.
+const maxPasses = 50
+
+// Plan orders the stops of an open trip from stop 0 (the start) through the pickups 2i+1 and dropoffs
+// 2i+2 of len(cost)/2 jobs, visiting each pickup before its dropoff. cost[a][b] is the cost of going from
+// a to b; the trip ends at its last stop. Jobs are inserted cheapest first, then segments of up to three
+// stops are moved (Or-opt) and reversed (2-opt) while that lowers the total.
+func Plan(cost [][]float64) []int {
+	p := planner{cost: cost}
+	seq := p.insert()
+	for pass := 0; pass < maxPasses; pass++ {
+		if !p.orOpt(&seq) && !p.twoOpt(&seq) {
+			break
+		}
+	}
+	return seq
+}
+
+// Cost is the total of cost over the trip from stop 0 through seq.
+func Cost(cost [][]float64, seq []int) float64 {
+	total, prev := 0.0, 0
+	for _, s := range seq {
+		total += cost[prev][s]
+		prev = s
+	}
+	return total
+}
+
+// improvement is the least drop in total cost a move must make, against float noise.
+const improvement = 1e-9
+
+type planner struct {
+	cost [][]float64
+}
+
+// c is the cost from a to b, where b -1 is the end of the trip.
+func (p planner) c(a, b int) float64 {
+	if b < 0 {
+		return 0
+	}
+	return p.cost[a][b]
+}
+
+// insert builds a trip by repeatedly inserting the job whose pickup and dropoff add the least.
+func (p planner) insert() []int {
+	jobs := len(p.cost) / 2
+	done := make([]bool, jobs)
+	trip := []int{0}
+	next := func(i int) int {
+		if i+1 < len(trip) {
+			return trip[i+1]
+		}
+		return -1
+	}
+	for range done {
+		bestJob, bestI, bestJ, best := -1, 0, 0, math.Inf(1)
+		for job := range done {
+			if done[job] {
+				continue
+			}
+			pu, do := 2*job+1, 2*job+2
+			for i := range trip {
+				a, b := trip[i], next(i)
+				// Dropoff straight after the pickup.
+				if d := p.c(a, pu) + p.cost[pu][do] + p.c(do, b) - p.c(a, b); d < best {
+					bestJob, bestI, bestJ, best = job, i, i, d
+				}
+				dp := p.c(a, pu) + p.c(pu, b) - p.c(a, b)
+				for j := i + 1; j < len(trip); j++ {
+					if d := dp + p.c(trip[j], do) + p.c(do, next(j)) - p.c(trip[j], next(j)); d < best {
+						bestJob, bestI, bestJ, best = job, i, j, d
+					}
+				}
+			}
+		}
+		done[bestJob] = true
+		pu, do := 2*bestJob+1, 2*bestJob+2
+		// Insert the dropoff first so that bestI still points at the pickup's position.
+		trip = insertAt(trip, bestJ+1, do)
+		trip = insertAt(trip, bestI+1, pu)
+	}
+	return trip[1:]
+}
+
+func insertAt(s []int, i, v int) []int {
+	s = append(s, 0)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// valid reports whether every pickup in seq comes before its dropoff.
+func valid(seq []int) bool {
+	picked := map[int]bool{}
+	for _, s := range seq {
+		if s%2 == 1 {
+			picked[s] = true
+		} else if !picked[s-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// orOpt moves segments of one to three stops elsewhere in the trip, keeping any move that lowers the total.
+func (p planner) orOpt(seq *[]int) bool {
+	improved := false
+	cur := Cost(p.cost, *seq)
+	for k := 1; k <= 3; k++ {
+		for i := 0; i+k <= len(*seq); i++ {
+			seg := append([]int(nil), (*seq)[i:i+k]...)
+			rest := append(append([]int(nil), (*seq)[:i]...), (*seq)[i+k:]...)
+			for j := 0; j <= len(rest); j++ {
+				if j == i {
+					continue
+				}
+				cand := append(append(append(make([]int, 0, len(*seq)), rest[:j]...), seg...), rest[j:]...)
+				if c := Cost(p.cost, cand); c < cur-improvement && valid(cand) {
+					*seq, cur, improved = cand, c, true
+					break
+				}
+			}
+		}
+	}
+	return improved
+}
+
+// twoOpt reverses stretches of the trip, keeping any reversal that lowers the total. Costs need not be
+// symmetric, so each candidate is costed in full.
+func (p planner) twoOpt(seq *[]int) bool {
+	improved := false
+	cur := Cost(p.cost, *seq)
+	for i := 0; i < len(*seq)-1; i++ {
+		for j := i + 2; j <= len(*seq); j++ {
+			cand := append([]int(nil), *seq...)
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				cand[a], cand[b] = cand[b], cand[a]
+			}
+			if c := Cost(p.cost, cand); c < cur-improvement && valid(cand) {
+				*seq, cur, improved = cand, c, true
+			}
+		}
+	}
+	return improved
+}
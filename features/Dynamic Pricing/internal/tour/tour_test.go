@@ -0,0 +1,160 @@
+package tour
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// line is the cost matrix of stops at positions xs along a road, start first.
+func line(xs ...float64) [][]float64 {
+	cost := make([][]float64, len(xs))
+	for i := range xs {
+		cost[i] = make([]float64, len(xs))
+		for j := range xs {
+			cost[i][j] = math.Abs(xs[i] - xs[j])
+		}
+	}
+	return cost
+}
+
+// plane is the cost matrix of straight-line travel between random points in the unit square.
+func plane(rnd *rand.Rand, stops int) [][]float64 {
+	pts := make([][2]float64, stops)
+	for i := range pts {
+		pts[i] = [2]float64{rnd.Float64(), rnd.Float64()}
+	}
+	cost := make([][]float64, stops)
+	for i := range pts {
+		cost[i] = make([]float64, stops)
+		for j := range pts {
+			cost[i][j] = math.Hypot(pts[i][0]-pts[j][0], pts[i][1]-pts[j][1])
+		}
+	}
+	return cost
+}
+
+// optimum is the least Cost of any trip over the stops of cost that picks up each job before dropping it
+// off, found by trying them all.
+func optimum(cost [][]float64) float64 {
+	n := len(cost) - 1
+	best := math.Inf(1)
+	seen := make([]bool, n+1)
+	seq := make([]int, 0, n)
+	var extend func()
+	extend = func() {
+		if len(seq) == n {
+			best = math.Min(best, Cost(cost, seq))
+			return
+		}
+		for s := 1; s <= n; s++ {
+			if seen[s] || (s%2 == 0 && !seen[s-1]) {
+				continue
+			}
+			seen[s] = true
+			seq = append(seq, s)
+			extend()
+			seq = seq[:len(seq)-1]
+			seen[s] = false
+		}
+	}
+	extend()
+	return best
+}
+
+// checkTrip fails t unless seq visits every stop of cost but the start once, each pickup before its dropoff.
+func checkTrip(t *testing.T, cost [][]float64, seq []int) {
+	t.Helper()
+	if len(seq) != len(cost)-1 {
+		t.Fatalf("trip %v has %d stops, want %d", seq, len(seq), len(cost)-1)
+	}
+	at := map[int]int{}
+	for i, s := range seq {
+		if s < 1 || s >= len(cost) {
+			t.Fatalf("trip %v visits unknown stop %d", seq, s)
+		}
+		if _, ok := at[s]; ok {
+			t.Fatalf("trip %v visits stop %d twice", seq, s)
+		}
+		at[s] = i
+	}
+	for pu := 1; pu < len(cost); pu += 2 {
+		if at[pu] > at[pu+1] {
+			t.Fatalf("trip %v drops off job %d before picking it up", seq, pu/2)
+		}
+	}
+}
+
+func TestPlanFindsOptimum(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]float64
+		want []int
+	}{
+		{"one job", line(0, 1, 2), []int{1, 2}},
+		{"dropoff behind the start", line(0, 5, -1), []int{1, 2}},
+		{"dropoff next to the start", line(0, 10, 0.1), []int{1, 2}},
+		{"two jobs in a row", line(0, 1, 3, 2, 4), []int{1, 3, 2, 4}},
+		{"two jobs nested", line(0, 1, 4, 2, 3), []int{1, 3, 4, 2}},
+		{"two jobs apart", line(0, 1, 2, 10, 11), []int{1, 2, 3, 4}},
+		{"two jobs back to the start", line(0, 10, 0.1, 10.1, 0.2), []int{1, 3, 4, 2}},
+		{"three jobs out", line(0, 1, 4, 2, 5, 3, 6), []int{1, 3, 5, 2, 4, 6}},
+		{"three jobs back to the start", line(0, 10, 0.3, 10.1, 0.2, 10.2, 0.1), []int{1, 3, 5, 2, 4, 6}},
+		{"three jobs on both sides", line(0, -1, -2, 1, 2, -3, -4), []int{3, 4, 1, 2, 5, 6}},
+		{"one-way loop", [][]float64{
+			{0, 1, 9, 9, 9},
+			{9, 0, 9, 1, 9},
+			{9, 9, 0, 9, 1},
+			{9, 9, 1, 0, 9},
+			{9, 9, 9, 9, 0},
+		}, []int{1, 3, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best := optimum(tt.cost)
+			if want := Cost(tt.cost, tt.want); math.Abs(want-best) > 1e-9 {
+				t.Fatalf("want %v costs %v, but the optimum is %v", tt.want, want, best)
+			}
+			got := Plan(tt.cost)
+			checkTrip(t, tt.cost, got)
+			if c := Cost(tt.cost, got); c > best+1e-9 {
+				t.Errorf("Plan = %v costing %v, want %v costing %v", got, c, tt.want, best)
+			}
+		})
+	}
+}
+
+func TestPlanNoJobs(t *testing.T) {
+	if got := Plan([][]float64{{0}}); len(got) != 0 {
+		t.Errorf("Plan of the start alone = %v, want an empty trip", got)
+	}
+}
+
+// Plan is a heuristic: from three jobs on it misses the optimum on some instances, on these about one
+// three-job trip in ten (one in thirty over one to three jobs), by up to 15%. Pin how often and by how
+// much, so that a change making it worse shows.
+func TestPlanQualityOnRandomInstances(t *testing.T) {
+	const runs = 300
+	for jobs := 1; jobs <= 3; jobs++ {
+		rnd := rand.New(rand.NewSource(int64(jobs)))
+		missed, worst := 0, 1.0
+		for run := 0; run < runs; run++ {
+			cost := plane(rnd, 2*jobs+1)
+			seq := Plan(cost)
+			checkTrip(t, cost, seq)
+			if got, best := Cost(cost, seq), optimum(cost); got > best+1e-9 {
+				missed++
+				worst = math.Max(worst, got/best)
+			}
+		}
+		maxMissed, maxRatio := 0, 1.0
+		if jobs == 3 {
+			maxMissed, maxRatio = runs/8, 1.2
+		}
+		if missed > maxMissed || worst > maxRatio {
+			t.Errorf("%d jobs: %d of %d trips above the optimum, the worst by %.1f%%; want at most %d and %.0f%%",
+				jobs, missed, runs, 100*(worst-1), maxMissed, 100*(maxRatio-1))
+		}
+		t.Logf("%d jobs: %d of %d trips above the optimum, the worst by %.1f%%", jobs, missed, runs, 100*(worst-1))
+	}
+}
@@ -0,0 +1,182 @@
+package traffic
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeekHours is the length of a Profile: one slot per hour of the week, Monday 00:00 first.
+const WeekHours = 7 * 24
+
+// Profile is the expected congestion (0..1) of a zone or edge in each hour of the week, in the time zone
+// it was fitted in.
+type Profile [WeekHours]float64
+
+// HourOfWeek is the Profile slot of t in loc.
+func HourOfWeek(t time.Time, loc *time.Location) int {
+	t = t.In(loc)
+	return (int(t.Weekday())+6)%7*24 + t.Hour()
+}
+
+// At is the congestion at t, interpolated linearly between the middles of the hours. Travel times then
+// change by at most an edge's free-flow time per hour of departure delay, so on any edge shorter than
+// an hour leaving later never arrives earlier, which time-dependent Dijkstra relies on. loc is the time
+// zone the profile was fitted in.
+func (p *Profile) At(t time.Time, loc *time.Location) float64 {
+	t = t.In(loc)
+	h := float64(HourOfWeek(t, loc)) + float64(t.Minute())/60 + float64(t.Second())/3600 - 0.5
+	if h < 0 {
+		h += WeekHours
+	}
+	i := int(h)
+	f := h - float64(i)
+	return p[i]*(1-f) + p[(i+1)%WeekHours]*f
+}
+
+// Samples accumulates congestion observations per hour of the week.
+type Samples struct {
+	sum [WeekHours]float64
+	n   [WeekHours]int
+}
+
+func (s *Samples) Add(slot int, congestion float64) {
+	s.sum[slot] += congestion
+	s.n[slot]++
+}
+
+// Count is the number of observations added.
+func (s *Samples) Count() int {
+	total := 0
+	for _, n := range s.n {
+		total += n
+	}
+	return total
+}
+
+// Profile is the mean of each slot. A slot without observations takes the mean of the same hour on the
+// other days, or else the overall mean.
+func (s *Samples) Profile() Profile {
+	var p Profile
+	var hourSum [24]float64
+	var hourN [24]int
+	total, totalN := 0.0, 0
+	for i := range s.sum {
+		hourSum[i%24] += s.sum[i]
+		hourN[i%24] += s.n[i]
+		total += s.sum[i]
+		totalN += s.n[i]
+	}
+	for i := range p {
+		switch {
+		case s.n[i] > 0:
+			p[i] = s.sum[i] / float64(s.n[i])
+		case hourN[i%24] > 0:
+			p[i] = hourSum[i%24] / float64(hourN[i%24])
+		case totalN > 0:
+			p[i] = total / float64(totalN)
+		}
+	}
+	return p
+}
+
+// Profiles are the profiles of grid zones and road edges, keyed by zone ID and EdgeID.
+type Profiles struct {
+	Zones map[string]*Profile
+	Edges map[string]*Profile
+}
+
+func NewProfiles() *Profiles {
+	return &Profiles{Zones: map[string]*Profile{}, Edges: map[string]*Profile{}}
+}
+
+var weekdays = map[string]int{"mon": 0, "tue": 1, "wed": 2, "thu": 3, "fri": 4, "sat": 5, "sun": 6}
+
+// ReadProfiles reads a CSV of scope,id,day,hour,congestion rows after a header line, with # comments.
+// scope is zone or edge, day mon..sun and hour 0..23, either of them * for all; rows for the same slot
+// are averaged and slots without rows are filled as by Samples.Profile.
+func ReadProfiles(path string) (*Profiles, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 5
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	zones, edges := map[string]*Samples{}, map[string]*Samples{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := r.FieldPos(0)
+		set := zones
+		switch rec[0] {
+		case "zone":
+		case "edge":
+			set = edges
+		default:
+			return nil, fmt.Errorf("%s:%d: scope must be zone or edge", path, line)
+		}
+		days, ok := slotRange(strings.ToLower(rec[2]), 7, func(v string) (int, bool) { d, ok := weekdays[v]; return d, ok })
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: invalid day %q", path, line, rec[2])
+		}
+		hours, ok := slotRange(rec[3], 24, func(v string) (int, bool) {
+			h, err := strconv.Atoi(v)
+			return h, err == nil && h >= 0 && h < 24
+		})
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: invalid hour %q", path, line, rec[3])
+		}
+		c, err := strconv.ParseFloat(rec[4], 64)
+		if err != nil || c < 0 || c > 1 {
+			return nil, fmt.Errorf("%s:%d: congestion must be within 0..1", path, line)
+		}
+		s := set[rec[1]]
+		if s == nil {
+			s = &Samples{}
+			set[rec[1]] = s
+		}
+		for _, d := range days {
+			for _, h := range hours {
+				s.Add(d*24+h, c)
+			}
+		}
+	}
+	out := NewProfiles()
+	for id, s := range zones {
+		p := s.Profile()
+		out.Zones[id] = &p
+	}
+	for id, s := range edges {
+		p := s.Profile()
+		out.Edges[id] = &p
+	}
+	return out, nil
+}
+
+// slotRange parses one value, or * for 0..n-1.
+func slotRange(v string, n int, parse func(string) (int, bool)) ([]int, bool) {
+	if v == "*" {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, true
+	}
+	i, ok := parse(v)
+	return []int{i}, ok
+}
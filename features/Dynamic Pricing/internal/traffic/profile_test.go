@@ -0,0 +1,45 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHourOfWeekInLocation(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	// Monday 00:30 UTC is Monday 06:00 in Kolkata; Sunday 20:00 UTC is Monday 01:30.
+	tests := []struct {
+		t    time.Time
+		loc  *time.Location
+		want int
+	}{
+		{time.Date(2026, 10, 19, 0, 30, 0, 0, time.UTC), time.UTC, 0},
+		{time.Date(2026, 10, 19, 0, 30, 0, 0, time.UTC), kolkata, 6},
+		{time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), time.UTC, WeekHours - 4},
+		{time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), kolkata, 1},
+	}
+	for _, tt := range tests {
+		if got := HourOfWeek(tt.t, tt.loc); got != tt.want {
+			t.Errorf("HourOfWeek(%v, %s) = %d, want %d", tt.t, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestProfileAtInterpolates(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	var p Profile
+	p[8], p[9] = 0.2, 0.6 // Monday 08:00 and 09:00
+	tests := []struct {
+		t    time.Time
+		want float64
+	}{
+		{time.Date(2026, 10, 19, 8, 30, 0, 0, kolkata), 0.2},
+		{time.Date(2026, 10, 19, 9, 0, 0, 0, kolkata), 0.4},
+		{time.Date(2026, 10, 19, 4, 0, 0, 0, time.UTC), 0.6}, // 09:30 in Kolkata
+	}
+	for _, tt := range tests {
+		if got := p.At(tt.t, kolkata); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("At(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package traffic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"VOID/internal/cache"
+)
+
+// ZoneKey and EdgeKey are the cache keys the freshest congestion reading of a grid zone or road
+// segment is kept under, as "<congestion>@<observed unix nanos>".
+func ZoneKey(zone string) string {
+	return "traffic:zone:" + zone
+}
+
+func EdgeKey(edge string) string {
+	return "traffic:edge:" + edge
+}
+
+// EdgeID names the directed road segment from one routing node to another.
+func EdgeID(from, to string) string {
+	return from + "->" + to
+}
+
+// SplitEdgeID returns the node IDs of an EdgeID.
+func SplitEdgeID(id string) (from, to string, ok bool) {
+	return strings.Cut(id, "->")
+}
+
+// Publish stores a reading under key until observedAt+ttl, unless the cache already holds a newer one.
+// Readings that have already expired are dropped.
+func Publish(c *cache.Cache, key string, congestion float64, observedAt time.Time, ttl time.Duration) error {
+	remaining := time.Until(observedAt.Add(ttl))
+	if remaining <= 0 {
+		return nil
+	}
+	if _, at, ok := Latest(c, key); ok && !observedAt.After(at) {
+		return nil
+	}
+	return c.Set(key, fmt.Sprintf("%g@%d", congestion, observedAt.UnixNano()), remaining)
+}
+
+// Latest returns the cached reading under key. ok is false when there is none.
+func Latest(c *cache.Cache, key string) (congestion float64, observedAt time.Time, ok bool) {
+	if c == nil {
+		return 0, time.Time{}, false
+	}
+	v, err := c.Get(key)
+	if err != nil || v == "" {
+		return 0, time.Time{}, false
+	}
+	val, at, found := strings.Cut(v, "@")
+	if !found {
+		return 0, time.Time{}, false
+	}
+	congestion, err = strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(at, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return congestion, time.Unix(0, nanos), true
+}
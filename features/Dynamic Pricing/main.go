@@ -9,13 +9,30 @@ import (
 	"VOID/internal/api"
 	"VOID/internal/cache"
 	"VOID/internal/db"
+	"VOID/internal/pricing"
+	"VOID/internal/routing"
+	"VOID/internal/services"
+	"VOID/internal/surge"
 	"VOID/internal/ws"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	cfg := config.LoadConfig()
+	if err := pricing.Validate(cfg.Live(), cfg.Subscriptions.Plans); err != nil {
+		log.Fatalf("invalid pricing config: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		runBacktest(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "contract" {
+		runContract(cfg, os.Args[2:])
+		return
+	}
 
 	// DB init (sqlite default; use DATABASE_URL env for Postgres)
 	gormDB, err := db.InitDB(cfg)
@@ -23,13 +40,62 @@ func main() {
 		log.Fatalf("db init failed: %v", err)
 	}
 
-	if err := db.AutoMigrate(gormDB); err != nil {
-		log.Fatalf("auto migrate failed: %v", err)
+	migrate(gormDB, cfg)
+
+	// Pricing config versions: store the file if it changed, then run the latest stored version
+	watcher := services.NewPricingConfigWatcher(gormDB, cfg)
+	if err := watcher.Sync(); err != nil {
+		log.Printf("pricing config sync: %v", err)
 	}
+	go watcher.Run()
 
 	// Cache (Redis optional)
 	cacheClient := cache.NewCache(cfg)
 
+	weather, err := pricing.NewWeatherProvider(cfg, cacheClient)
+	if err != nil {
+		log.Fatalf("weather provider: %v", err)
+	}
+	services.SetWeatherProvider(weather)
+
+	if cfg.Routing.CHFile != "" {
+		h, err := loadHierarchy(cfg)
+		if err != nil {
+			log.Fatalf("contraction hierarchy: %v", err)
+		}
+		log.Printf("road graph: %d nodes, %d edges, %d shortcuts", len(h.Graph.Nodes), len(h.Graph.Edges), h.Shortcuts())
+		services.SetRoadHierarchy(h)
+	} else if cfg.Routing.OSMFile != "" {
+		graph, err := routing.Load(cfg.Routing.OSMFile, routing.Options{Speeds: cfg.Routing.Speeds})
+		if err != nil {
+			log.Fatalf("road graph: %v", err)
+		}
+		log.Printf("road graph: %d nodes, %d edges", len(graph.Nodes), len(graph.Edges))
+		services.SetRoadGraph(graph)
+	}
+
+	if err := services.LoadTrafficProfiles(gormDB, cfg); err != nil {
+		log.Fatalf("traffic profiles: %v", err)
+	}
+	if cfg.Traffic.Profiles.Learn {
+		go services.RunTrafficProfileFits(gormDB, cfg)
+	}
+
+	if cfg.Forecast.Enabled {
+		go services.RunForecastRefits(gormDB, cfg)
+	}
+	if cfg.Elasticity.Enabled {
+		go services.RunElasticityEstimates(gormDB, cfg)
+	}
+
+	// Surge controller
+	if cfg.Surge.Enabled {
+		controller := surge.NewController(cfg, cacheClient, func() map[string]float64 {
+			return services.ZoneDemandIndices(gormDB, cfg)
+		})
+		go controller.Run()
+	}
+
 	// WebSocket manager
 	manager := ws.NewManager()
 	go manager.Run()
@@ -45,3 +111,13 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// migrate brings the schema up to date; every command that opens the database runs it first.
+func migrate(gormDB *gorm.DB, cfg *config.Config) {
+	if err := db.AutoMigrate(gormDB); err != nil {
+		log.Fatalf("auto migrate failed: %v", err)
+	}
+	if err := db.MigrateMoney(gormDB, cfg.Pricing.Currency); err != nil {
+		log.Fatalf("money migration failed: %v", err)
+	}
+}